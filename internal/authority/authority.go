@@ -0,0 +1,456 @@
+// Package authority certifies the ed25519 keys of the nodes, with which they
+// sign the records of the mesh.
+package authority
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+var (
+	ErrUnsigned           = errors.New("record is not signed")
+	ErrExpired            = errors.New("certificate expired")
+	ErrInvalidCertificate = errors.New("invalid certificate")
+	ErrInvalidSignature   = errors.New("invalid record signature")
+	ErrReplayed           = errors.New("record older than a record already seen")
+)
+
+// GenerateKey returns a new ed25519 key for an authority or a node.
+func GenerateKey() (ed25519.PrivateKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+func EncodePrivateKey(privateKey ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(privateKey.Seed())
+}
+
+// ParsePrivateKey parses a key encoded by EncodePrivateKey.
+func ParsePrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key length %d", len(seed))
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func EncodePublicKey(publicKey ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(publicKey)
+}
+
+// ParsePublicKey parses a key encoded by EncodePublicKey.
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key length %d", len(publicKey))
+	}
+
+	return publicKey, nil
+}
+
+// Sign certifies nodeKey for the node named name, with the wireguard key
+// publicKey and its addresses within prefixes, until notAfter.
+func Sign(authorityKey ed25519.PrivateKey, name string, nodeKey ed25519.PublicKey, publicKey types.PublicKey, prefixes []net.IPNet, notAfter time.Time) types.Certificate {
+	certificate := types.Certificate{
+		Name:      name,
+		NodeKey:   nodeKey,
+		PublicKey: publicKey,
+		NotAfter:  notAfter.UTC().Truncate(time.Second),
+	}
+	for _, prefix := range prefixes {
+		certificate.Prefixes = append(certificate.Prefixes, prefix.String())
+	}
+	certificate.Signature = ed25519.Sign(authorityKey, certificateMessage(certificate))
+
+	return certificate
+}
+
+func VerifyCertificate(certificate types.Certificate, authority ed25519.PublicKey, now time.Time) error {
+	if len(certificate.NodeKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: invalid node key", ErrInvalidCertificate)
+	}
+	for _, prefix := range certificate.Prefixes {
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			return fmt.Errorf("%w: invalid prefix %q", ErrInvalidCertificate, prefix)
+		}
+	}
+
+	if !ed25519.Verify(authority, certificateMessage(certificate), certificate.Signature) {
+		return fmt.Errorf("%w: not issued by the mesh authority", ErrInvalidCertificate)
+	}
+
+	if now.After(certificate.NotAfter) {
+		return fmt.Errorf("%w on %s", ErrExpired, certificate.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func certificateMessage(certificate types.Certificate) []byte {
+	message := []byte("ikto certificate\n")
+	message = strconv.AppendQuote(message, certificate.Name)
+	message = append(message, '\n')
+	message = append(message, EncodePublicKey(certificate.NodeKey)...)
+	message = append(message, '\n')
+	message = append(message, certificate.PublicKey.String()...)
+	message = append(message, '\n')
+	message = append(message, strings.Join(certificate.Prefixes, ",")...)
+	message = append(message, '\n')
+	message = strconv.AppendInt(message, certificate.NotAfter.Unix(), 10)
+
+	return message
+}
+
+// Covers reports whether the subnet cidr is within a certified prefix.
+func Covers(certificate types.Certificate, cidr string) bool {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	ones, bits := subnet.Mask.Size()
+
+	for _, prefix := range certificate.Prefixes {
+		_, ipnet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			continue
+		}
+
+		prefixOnes, prefixBits := ipnet.Mask.Size()
+		if prefixBits == bits && prefixOnes <= ones && ipnet.Contains(subnet.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Signer signs the records written by the local node.
+type Signer struct {
+	nodeKey     ed25519.PrivateKey
+	certificate types.Certificate
+
+	mutex  sync.Mutex
+	signed time.Time
+}
+
+func NewSigner(nodeKey ed25519.PrivateKey, certificate types.Certificate) *Signer {
+	return &Signer{
+		nodeKey:     nodeKey,
+		certificate: certificate,
+	}
+}
+
+// now returns increasing times, so that the records of the node are ordered
+// even if the clock goes back.
+func (s *Signer) now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now().UTC()
+	if !now.After(s.signed) {
+		now = s.signed.Add(time.Nanosecond)
+	}
+	s.signed = now
+
+	return now
+}
+
+// Sign returns the encoded and signed record of peer.
+func (s *Signer) Sign(peer types.Peer) ([]byte, error) {
+	certificate := s.certificate
+	peer.Signed = s.now()
+	peer.Certificate = &certificate
+	peer.Signature = nil
+
+	signature, err := s.signature("peer", peer)
+	if err != nil {
+		return nil, err
+	}
+	peer.Signature = signature
+
+	return json.Marshal(peer)
+}
+
+func (s *Signer) SignEndpoints(endpoints types.ObservedEndpoints) ([]byte, error) {
+	certificate := s.certificate
+	endpoints.Signed = s.now()
+	endpoints.Certificate = &certificate
+	endpoints.Signature = nil
+
+	signature, err := s.signature("endpoints", endpoints)
+	if err != nil {
+		return nil, err
+	}
+	endpoints.Signature = signature
+
+	return json.Marshal(endpoints)
+}
+
+func (s *Signer) SignRelays(relays types.RelayPaths) ([]byte, error) {
+	certificate := s.certificate
+	relays.Signed = s.now()
+	relays.Certificate = &certificate
+	relays.Signature = nil
+
+	signature, err := s.signature("relays", relays)
+	if err != nil {
+		return nil, err
+	}
+	relays.Signature = signature
+
+	return json.Marshal(relays)
+}
+
+func (s *Signer) SignApproval(approval types.Approval) ([]byte, error) {
+	certificate := s.certificate
+	approval.Signed = s.now()
+	approval.Certificate = &certificate
+	approval.Signature = nil
+
+	signature, err := s.signature("approval", approval)
+	if err != nil {
+		return nil, err
+	}
+	approval.Signature = signature
+
+	return json.Marshal(approval)
+}
+
+func (s *Signer) signature(kind string, record any) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := recordMessage(kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return ed25519.Sign(s.nodeKey, message), nil
+}
+
+// Verifier verifies the records of the mesh against its authority.
+type Verifier struct {
+	authority ed25519.PublicKey
+
+	mutex sync.Mutex
+	// seen is the latest signing time of each record.
+	seen map[string]time.Time
+}
+
+func NewVerifier(authority ed25519.PublicKey) *Verifier {
+	return &Verifier{
+		authority: authority,
+		seen:      make(map[string]time.Time),
+	}
+}
+
+// Verify checks that the record is signed with a key certified for the name,
+// the public key and the addresses of the peer.
+func (v *Verifier) Verify(data []byte) error {
+	var peer types.Peer
+	if err := json.Unmarshal(data, &peer); err != nil {
+		return err
+	}
+
+	if err := v.verify("peer", data, peer.Name, peer.Certificate, peer.Signature); err != nil {
+		return err
+	}
+	if err := certifies(*peer.Certificate, peer.PublicKey, peer.AllowedIP, peer.SecondaryIP); err != nil {
+		return err
+	}
+
+	return v.fresh("peer."+peer.Name, peer.Signed)
+}
+
+func (v *Verifier) VerifyEndpoints(data []byte) error {
+	var endpoints types.ObservedEndpoints
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return err
+	}
+
+	if err := v.verify("endpoints", data, endpoints.Name, endpoints.Certificate, endpoints.Signature); err != nil {
+		return err
+	}
+	if err := certifies(*endpoints.Certificate, endpoints.PublicKey); err != nil {
+		return err
+	}
+
+	return v.fresh("endpoints."+endpoints.Name, endpoints.Signed)
+}
+
+func (v *Verifier) VerifyRelays(data []byte) error {
+	var relays types.RelayPaths
+	if err := json.Unmarshal(data, &relays); err != nil {
+		return err
+	}
+
+	if err := v.verify("relays", data, relays.Name, relays.Certificate, relays.Signature); err != nil {
+		return err
+	}
+	if err := certifies(*relays.Certificate, relays.PublicKey); err != nil {
+		return err
+	}
+
+	return v.fresh("relays."+relays.Name, relays.Signed)
+}
+
+// VerifyApproval also verifies the previous approval of a rotated key.
+func (v *Verifier) VerifyApproval(data []byte) error {
+	var approval types.Approval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return err
+	}
+
+	if err := v.verify("approval", data, approval.Approver, approval.Certificate, approval.Signature); err != nil {
+		return err
+	}
+	if approval.Previous != nil {
+		previous, err := json.Marshal(approval.Previous)
+		if err != nil {
+			return err
+		}
+		if err := v.VerifyApproval(previous); err != nil {
+			return fmt.Errorf("previous approval: %w", err)
+		}
+	}
+
+	return v.fresh("approval."+approval.PublicKey.String(), approval.Signed)
+}
+
+func (v *Verifier) verify(kind string, data []byte, name string, certificate *types.Certificate, signature []byte) error {
+	if certificate == nil || len(signature) == 0 {
+		return ErrUnsigned
+	}
+
+	if err := VerifyCertificate(*certificate, v.authority, time.Now()); err != nil {
+		return err
+	}
+
+	if certificate.Name != name {
+		return fmt.Errorf("%w: certificate issued to %q", ErrInvalidCertificate, certificate.Name)
+	}
+
+	message, err := recordMessage(kind, data)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(certificate.NodeKey, message, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// certifies checks that the certificate binds publicKey and the addresses.
+func certifies(certificate types.Certificate, publicKey types.PublicKey, addresses ...string) error {
+	if certificate.PublicKey != publicKey {
+		return fmt.Errorf("%w: issued for the wireguard key %s", ErrInvalidCertificate, certificate.PublicKey.String())
+	}
+
+	for _, address := range addresses {
+		if address != "" && !Covers(certificate, address) {
+			return fmt.Errorf("%w: %s is outside of the certified prefixes", ErrInvalidCertificate, address)
+		}
+	}
+
+	return nil
+}
+
+// fresh rejects a record signed before the latest one seen with the same key.
+func (v *Verifier) fresh(key string, signed time.Time) error {
+	if signed.IsZero() {
+		return fmt.Errorf("%w: no signing time", ErrUnsigned)
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if signed.Before(v.seen[key]) {
+		return fmt.Errorf("%w: signed on %s", ErrReplayed, signed.Format(time.RFC3339Nano))
+	}
+	v.seen[key] = signed
+
+	return nil
+}
+
+// SignPolicy returns policy signed by the authority.
+func SignPolicy(authorityKey ed25519.PrivateKey, policy types.Policy) (types.Policy, error) {
+	policy.Signature = nil
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return types.Policy{}, err
+	}
+
+	message, err := recordMessage("policy", data)
+	if err != nil {
+		return types.Policy{}, err
+	}
+
+	policy.Signature = ed25519.Sign(authorityKey, message)
+
+	return policy, nil
+}
+
+func (v *Verifier) VerifyPolicy(data []byte) error {
+	var policy types.Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return err
+	}
+
+	if len(policy.Signature) == 0 {
+		return ErrUnsigned
+	}
+
+	message, err := recordMessage("policy", data)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(v.authority, message, policy.Signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// recordMessage does not depend on the fields known to the agent, so that
+// agents of different versions agree on it.
+func recordMessage(kind string, data []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "signature")
+
+	message, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte("ikto "+kind+"\n"), message...), nil
+}
@@ -0,0 +1,314 @@
+package authority
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+func generateKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+var prefixes = []net.IPNet{{IP: net.ParseIP("fd10::"), Mask: net.CIDRMask(64, 128)}}
+
+func signedRecord(t *testing.T, authorityKey ed25519.PrivateKey, name string, notAfter time.Time) []byte {
+	t.Helper()
+
+	return signedPeer(t, authorityKey, name, notAfter, types.Peer{
+		Name:             "node",
+		AdvertiseAddress: "192.0.2.1",
+		AllowedIP:        "fd10::1/128",
+		WGPort:           51820,
+	})
+}
+
+func signedPeer(t *testing.T, authorityKey ed25519.PrivateKey, name string, notAfter time.Time, peer types.Peer) []byte {
+	t.Helper()
+
+	nodeKey := generateKey(t)
+	certificate := Sign(authorityKey, name, nodeKey.Public().(ed25519.PublicKey), types.PublicKey{}, prefixes, notAfter)
+
+	data, err := NewSigner(nodeKey, certificate).Sign(peer)
+	if err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+	return data
+}
+
+func setField(t *testing.T, data []byte, name string, value any) []byte {
+	t.Helper()
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+	fields[name] = value
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatalf("failed to encode record: %v", err)
+	}
+	return data
+}
+
+func TestVerify(t *testing.T) {
+	authorityKey := generateKey(t)
+	verifier := NewVerifier(authorityKey.Public().(ed25519.PublicKey))
+	notAfter := time.Now().Add(time.Hour)
+
+	record := signedRecord(t, authorityKey, "node", notAfter)
+	if err := verifier.Verify(record); err != nil {
+		t.Fatalf("expected a valid record, got %v", err)
+	}
+
+	unsigned, err := json.Marshal(types.Peer{Name: "node", AllowedIP: "fd10::1/128"})
+	if err != nil {
+		t.Fatalf("failed to encode record: %v", err)
+	}
+
+	tests := map[string]struct {
+		record []byte
+		err    error
+	}{
+		"unsigned":        {unsigned, ErrUnsigned},
+		"tampered":        {setField(t, record, "allowed_ip", "fd10::2/128"), ErrInvalidSignature},
+		"unknown field":   {setField(t, record, "future_field", true), ErrInvalidSignature},
+		"expired":         {signedRecord(t, authorityKey, "node", time.Now().Add(-time.Hour)), ErrExpired},
+		"other authority": {signedRecord(t, generateKey(t), "node", notAfter), ErrInvalidCertificate},
+		"other name":      {signedRecord(t, authorityKey, "other", notAfter), ErrInvalidCertificate},
+		"other wireguard key": {signedPeer(t, authorityKey, "node", notAfter, types.Peer{
+			Name:      "node",
+			PublicKey: types.PublicKey{1},
+			AllowedIP: "fd10::1/128",
+		}), ErrInvalidCertificate},
+		"outside of the prefixes": {signedPeer(t, authorityKey, "node", notAfter, types.Peer{
+			Name:      "node",
+			AllowedIP: "fd11::1/128",
+		}), ErrInvalidCertificate},
+		"wider than the prefixes": {signedPeer(t, authorityKey, "node", notAfter, types.Peer{
+			Name:      "node",
+			AllowedIP: "fd10::/48",
+		}), ErrInvalidCertificate},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := verifier.Verify(test.record); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	authorityKey := generateKey(t)
+	verifier := NewVerifier(authorityKey.Public().(ed25519.PublicKey))
+
+	nodeKey := generateKey(t)
+	signer := NewSigner(nodeKey, Sign(authorityKey, "node", nodeKey.Public().(ed25519.PublicKey), types.PublicKey{}, prefixes, time.Now().Add(time.Hour)))
+
+	var records [][]byte
+	for _, allowedIP := range []string{"fd10::1/128", "fd10::2/128"} {
+		data, err := signer.Sign(types.Peer{Name: "node", AllowedIP: allowedIP})
+		if err != nil {
+			t.Fatalf("failed to sign record: %v", err)
+		}
+		records = append(records, data)
+	}
+
+	if err := verifier.Verify(records[1]); err != nil {
+		t.Fatalf("expected a valid record, got %v", err)
+	}
+	if err := verifier.Verify(records[1]); err != nil {
+		t.Fatalf("expected the same record to stay valid, got %v", err)
+	}
+	if err := verifier.Verify(records[0]); !errors.Is(err, ErrReplayed) {
+		t.Fatalf("expected ErrReplayed, got %v", err)
+	}
+}
+
+func TestVerifyPolicy(t *testing.T) {
+	authorityKey := generateKey(t)
+	verifier := NewVerifier(authorityKey.Public().(ed25519.PublicKey))
+	policy := types.Policy{Name: "edges", Nodes: "role=edge", Peers: "role=hub"}
+
+	signPolicy := func(key ed25519.PrivateKey) []byte {
+		t.Helper()
+
+		signed, err := SignPolicy(key, policy)
+		if err != nil {
+			t.Fatalf("failed to sign policy: %v", err)
+		}
+		data, err := json.Marshal(signed)
+		if err != nil {
+			t.Fatalf("failed to encode policy: %v", err)
+		}
+		return data
+	}
+
+	signed := signPolicy(authorityKey)
+	if err := verifier.VerifyPolicy(signed); err != nil {
+		t.Fatalf("expected a valid policy, got %v", err)
+	}
+
+	unsigned, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatalf("failed to encode policy: %v", err)
+	}
+
+	tests := map[string]struct {
+		policy []byte
+		err    error
+	}{
+		"unsigned":        {unsigned, ErrUnsigned},
+		"tampered":        {setField(t, signed, "peers", ""), ErrInvalidSignature},
+		"other authority": {signPolicy(generateKey(t)), ErrInvalidSignature},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := verifier.VerifyPolicy(test.policy); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyEndpoints(t *testing.T) {
+	authorityKey := generateKey(t)
+	verifier := NewVerifier(authorityKey.Public().(ed25519.PublicKey))
+
+	signEndpoints := func(name string) []byte {
+		t.Helper()
+
+		nodeKey := generateKey(t)
+		certificate := Sign(authorityKey, name, nodeKey.Public().(ed25519.PublicKey), types.PublicKey{}, prefixes, time.Now().Add(time.Hour))
+		data, err := NewSigner(nodeKey, certificate).SignEndpoints(types.ObservedEndpoints{
+			Name:      "node",
+			Endpoints: map[string]string{"peer": "203.0.113.1:51820"},
+			Time:      time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("failed to sign endpoints: %v", err)
+		}
+		return data
+	}
+
+	signed := signEndpoints("node")
+	if err := verifier.VerifyEndpoints(signed); err != nil {
+		t.Fatalf("expected valid endpoints, got %v", err)
+	}
+
+	unsigned, err := json.Marshal(types.ObservedEndpoints{Name: "node"})
+	if err != nil {
+		t.Fatalf("failed to encode endpoints: %v", err)
+	}
+
+	tests := map[string]struct {
+		endpoints []byte
+		err       error
+	}{
+		"unsigned":   {unsigned, ErrUnsigned},
+		"tampered":   {setField(t, signed, "endpoints", map[string]string{"peer": "198.51.100.1:1"}), ErrInvalidSignature},
+		"other name": {signEndpoints("other"), ErrInvalidCertificate},
+		"peer record": {func() []byte {
+			// A peer record signature does not cover observed endpoints.
+			return setField(t, signedRecord(t, authorityKey, "node", time.Now().Add(time.Hour)), "endpoints", map[string]string{})
+		}(), ErrInvalidSignature},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := verifier.VerifyEndpoints(test.endpoints); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyApproval(t *testing.T) {
+	authorityKey := generateKey(t)
+	verifier := NewVerifier(authorityKey.Public().(ed25519.PublicKey))
+
+	signApproval := func(name string) []byte {
+		t.Helper()
+
+		nodeKey := generateKey(t)
+		certificate := Sign(authorityKey, name, nodeKey.Public().(ed25519.PublicKey), types.PublicKey{}, prefixes, time.Now().Add(time.Hour))
+		data, err := NewSigner(nodeKey, certificate).SignApproval(types.Approval{
+			PublicKey: types.PublicKey{1},
+			Approver:  "node",
+			Time:      time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("failed to sign approval: %v", err)
+		}
+		return data
+	}
+
+	signed := signApproval("node")
+	if err := verifier.VerifyApproval(signed); err != nil {
+		t.Fatalf("expected a valid approval, got %v", err)
+	}
+
+	unsigned, err := json.Marshal(types.Approval{PublicKey: types.PublicKey{1}, Approver: "node"})
+	if err != nil {
+		t.Fatalf("failed to encode approval: %v", err)
+	}
+
+	var previous types.Approval
+	if err := json.Unmarshal(signed, &previous); err != nil {
+		t.Fatalf("failed to decode approval: %v", err)
+	}
+	rotated := func(previous types.Approval) []byte {
+		t.Helper()
+
+		nodeKey := generateKey(t)
+		certificate := Sign(authorityKey, "node", nodeKey.Public().(ed25519.PublicKey), types.PublicKey{}, prefixes, time.Now().Add(time.Hour))
+		data, err := NewSigner(nodeKey, certificate).SignApproval(types.Approval{
+			PublicKey: types.PublicKey{3},
+			Approver:  "node",
+			Previous:  &previous,
+		})
+		if err != nil {
+			t.Fatalf("failed to sign approval: %v", err)
+		}
+		return data
+	}
+
+	if err := verifier.VerifyApproval(rotated(previous)); err != nil {
+		t.Fatalf("expected a valid approval of a rotated key, got %v", err)
+	}
+	tampered := previous
+	tampered.PublicKey = types.PublicKey{2}
+
+	tests := map[string]struct {
+		approval []byte
+		err      error
+	}{
+		"unsigned":                   {unsigned, ErrUnsigned},
+		"tampered previous approval": {rotated(tampered), ErrInvalidSignature},
+		"tampered":                   {setField(t, signed, "public_key", types.PublicKey{2}), ErrInvalidSignature},
+		"other name":                 {signApproval("other"), ErrInvalidCertificate},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := verifier.VerifyApproval(test.approval); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
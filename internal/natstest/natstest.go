@@ -0,0 +1,76 @@
+// Package natstest starts an in-process NATS server with JetStream enabled
+// for tests.
+package natstest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// StartServer starts an in-process NATS server with JetStream enabled. The
+// server is shut down when the test ends.
+func StartServer(t testing.TB) *server.Server {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create nats server: %v", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("nats server is not ready for connections")
+	}
+
+	t.Cleanup(s.Shutdown)
+
+	return s
+}
+
+// Connect connects to s. The connection is closed when the test ends.
+func Connect(t testing.TB, s *server.Server) *nats.Conn {
+	t.Helper()
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect to nats: %v", err)
+	}
+
+	t.Cleanup(nc.Close)
+
+	return nc
+}
+
+// KeyValue creates the test KV bucket on s if needed and returns a handle on
+// it using a dedicated connection, so that several handles behave like
+// several nodes.
+func KeyValue(t testing.TB, s *server.Server) jetstream.KeyValue {
+	t.Helper()
+
+	js, err := jetstream.New(Connect(t, s))
+	if err != nil {
+		t.Fatalf("failed to create jetstream client: %v", err)
+	}
+
+	kv, err := js.CreateOrUpdateKeyValue(context.Background(), jetstream.KeyValueConfig{
+		Bucket:  "ikto-test",
+		Storage: jetstream.MemoryStorage,
+	})
+	if err != nil {
+		t.Fatalf("failed to create key value bucket: %v", err)
+	}
+
+	return kv
+}
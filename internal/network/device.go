@@ -0,0 +1,36 @@
+package network
+
+import (
+	"net"
+
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// Device manages the WireGuard interface of the local node.
+type Device interface {
+	Ensure() error
+	// SetAddr replaces the address of the same family.
+	SetAddr(ipnet net.IPNet) error
+	InitConfig() error
+	// SetTuning clears an unset firewall mark, but leaves an unset MTU or
+	// queue length untouched.
+	SetTuning(tuning types.Tuning) error
+	Tuning() (types.Tuning, error)
+	SetPrivateKey(privateKey wgtypes.Key) error
+	AddPeer(peer types.Peer) error
+	RemovePeer(publicKey wgtypes.Key) error
+	ReplacePeers(peers []types.Peer) error
+	// SwapPeer replaces previous by peer in a single configuration change.
+	SwapPeer(previous wgtypes.Key, peer types.Peer) error
+	// SetPresharedKey removes the key of the peer when key is nil.
+	SetPresharedKey(publicKey wgtypes.Key, key *wgtypes.Key) error
+	Probe(publicKey wgtypes.Key) error
+	EnableForwarding(family int) error
+	SetDefaultRoute(enabled bool) error
+	SetMasquerade(sources []net.IPNet, enabled bool) error
+	Remove() error
+	Device() (*wgtypes.Device, error)
+}
+
+var _ Device = (*WGDevice)(nil)
@@ -0,0 +1,194 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/valyentdev/ikto/pkg/types"
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+const (
+	// ExitFirewallMark keeps the encapsulated packets on the main table.
+	ExitFirewallMark = 0x696b
+	ExitRouteTable   = 0x696b
+
+	suppressRulePriority = 0x696a
+	exitRulePriority     = 0x696b
+	mainRouteTable       = 254
+)
+
+// DeviceTuning returns tuning as applied to the device, whose firewall mark
+// is the exit mark while the default route goes through the interface.
+func DeviceTuning(tuning types.Tuning, defaultRoute bool) types.Tuning {
+	if defaultRoute {
+		tuning.FirewallMark = ExitFirewallMark
+	}
+
+	return tuning
+}
+
+func defaultRoutes() []net.IPNet {
+	return []net.IPNet{
+		{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)},
+		{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)},
+	}
+}
+
+func exitRules(family int) []*netlink.Rule {
+	suppress := netlink.NewRule()
+	suppress.Family = family
+	suppress.Priority = suppressRulePriority
+	suppress.Table = mainRouteTable
+	suppress.SuppressPrefixlen = 0
+
+	exit := netlink.NewRule()
+	exit.Family = family
+	exit.Priority = exitRulePriority
+	exit.Table = ExitRouteTable
+	exit.Mark = ExitFirewallMark
+	exit.Invert = true
+
+	return []*netlink.Rule{suppress, exit}
+}
+
+// SetDefaultRoute uses policy routing, so the traffic of the device itself
+// keeps the main table.
+func (d *WGDevice) SetDefaultRoute(enabled bool) error {
+	link, err := d.ns.netlink.LinkByName(d.name)
+	if err != nil {
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	d.defaultRoute = enabled
+	mark := DeviceTuning(d.tuning, enabled).FirewallMark
+	err = d.wg.ConfigureDevice(d.name, wgtypes.Config{
+		FirewallMark: &mark,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set firewall mark: %w", err)
+	}
+
+	for _, route := range defaultRoutes() {
+		family := Family(route.IP)
+
+		if enabled {
+			err := d.ns.netlink.RouteReplace(&netlink.Route{
+				LinkIndex: link.Attrs().Index,
+				Dst:       &route,
+				Table:     ExitRouteTable,
+				Protocol:  routeProtocol,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add default route: %w", err)
+			}
+
+			if err := ensureRules(d.ns.netlink, family, exitRules(family)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, rule := range exitRules(family) {
+			err := d.ns.netlink.RuleDel(rule)
+			if err != nil && !errors.Is(err, syscall.ENOENT) {
+				return fmt.Errorf("failed to delete rule: %w", err)
+			}
+		}
+
+		err := d.ns.netlink.RouteDel(&netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       &route,
+			Table:     ExitRouteTable,
+		})
+		if err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("failed to delete default route: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func ensureRules(handle *netlink.Handle, family int, rules []*netlink.Rule) error {
+	current, err := handle.RuleList(family)
+	if err != nil {
+		return fmt.Errorf("failed to list rules: %w", err)
+	}
+
+	for _, rule := range rules {
+		exists := false
+		for _, existing := range current {
+			if existing.Priority == rule.Priority && existing.Table == rule.Table {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		if err := handle.RuleAdd(rule); err != nil {
+			return fmt.Errorf("failed to add rule: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *WGDevice) SetMasquerade(sources []net.IPNet, enabled bool) error {
+	return d.ns.do(func() error {
+		return d.setMasquerade(sources, enabled)
+	})
+}
+
+func (d *WGDevice) setMasquerade(sources []net.IPNet, enabled bool) error {
+	for _, source := range sources {
+		command := "iptables"
+		if Family(source.IP) == netlink.FAMILY_V6 {
+			command = "ip6tables"
+		}
+
+		rules := [][]string{
+			{"-t", "nat", "POSTROUTING", "-s", source.String(), "!", "-o", d.name, "-j", "MASQUERADE"},
+			{"-t", "filter", "FORWARD", "-i", d.name, "-s", source.String(), "-j", "ACCEPT"},
+			{"-t", "filter", "FORWARD", "-o", d.name, "-d", source.String(), "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"},
+		}
+
+		for _, rule := range rules {
+			if err := setIPTablesRule(command, rule, enabled); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func setIPTablesRule(command string, rule []string, present bool) error {
+	table, chain, spec := rule[:2], rule[2], rule[3:]
+	spec = append(spec, "-m", "comment", "--comment", "ikto")
+
+	run := func(action string) error {
+		args := append(append(append([]string{}, table...), action, chain), spec...)
+		output, err := exec.Command(command, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s %s: %w: %s", command, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	exists := run("-C") == nil
+	switch {
+	case present && !exists:
+		return run("-A")
+	case !present && exists:
+		return run("-D")
+	}
+
+	return nil
+}
@@ -0,0 +1,456 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// FakeDevice is an in-memory Device recording every call made to it.
+type FakeDevice struct {
+	mutex      sync.Mutex
+	name       string
+	port       int
+	privateKey wgtypes.Key
+	tuning     types.Tuning
+
+	exists     bool
+	device     wgtypes.Device
+	addrs      []net.IPNet
+	calls      []string
+	mtu        int
+	txQueueLen int
+
+	presharedKeys map[wgtypes.Key]wgtypes.Key
+
+	forwarding   map[int]bool
+	defaultRoute bool
+	masquerade   []net.IPNet
+}
+
+var _ Device = (*FakeDevice)(nil)
+
+func NewFakeDevice(name string, port int, privateKey wgtypes.Key) *FakeDevice {
+	return &FakeDevice{
+		name:       name,
+		port:       port,
+		privateKey: privateKey,
+		mtu:        1420,
+		txQueueLen: 1000,
+
+		presharedKeys: make(map[wgtypes.Key]wgtypes.Key),
+		device: wgtypes.Device{
+			Name: name,
+			Type: wgtypes.Unknown,
+		},
+	}
+}
+
+func (f *FakeDevice) record(format string, args ...any) {
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
+
+func (f *FakeDevice) Ensure() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("Ensure")
+	f.exists = true
+
+	return nil
+}
+
+func (f *FakeDevice) SetAddr(ipnet net.IPNet) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("SetAddr %s", ipnet.String())
+	if !f.exists {
+		return fmt.Errorf("failed to get link: link %s not found", f.name)
+	}
+	f.addrs = slices.DeleteFunc(f.addrs, func(addr net.IPNet) bool {
+		return Family(addr.IP) == Family(ipnet.IP)
+	})
+	f.addrs = append(f.addrs, ipnet)
+
+	return nil
+}
+
+func (f *FakeDevice) InitConfig() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("InitConfig")
+	f.device.PrivateKey = f.privateKey
+	f.device.PublicKey = f.privateKey.PublicKey()
+	f.device.ListenPort = f.port
+	f.device.FirewallMark = DeviceTuning(f.tuning, f.defaultRoute).FirewallMark
+
+	return nil
+}
+
+func (f *FakeDevice) SetTuning(tuning types.Tuning) error {
+	f.mutex.Lock()
+	f.record("SetTuning")
+	f.tuning = tuning
+	if tuning.MTU != 0 {
+		f.mtu = tuning.MTU
+	}
+	if tuning.TxQueueLen != 0 {
+		f.txQueueLen = tuning.TxQueueLen
+	}
+	f.mutex.Unlock()
+
+	return f.InitConfig()
+}
+
+func (f *FakeDevice) Tuning() (types.Tuning, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return types.Tuning{
+		MTU:          f.mtu,
+		FirewallMark: f.device.FirewallMark,
+		TxQueueLen:   f.txQueueLen,
+	}, nil
+}
+
+func (f *FakeDevice) SetPrivateKey(privateKey wgtypes.Key) error {
+	f.mutex.Lock()
+	f.record("SetPrivateKey %s", privateKey.PublicKey().String())
+	f.privateKey = privateKey
+	f.mutex.Unlock()
+
+	return f.InitConfig()
+}
+
+func (f *FakeDevice) AddPeer(peer types.Peer) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("AddPeer %s", peer.PublicKey.String())
+
+	return f.configurePeers([]types.Peer{peer})
+}
+
+func (f *FakeDevice) RemovePeer(publicKey wgtypes.Key) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("RemovePeer %s", publicKey.String())
+	delete(f.presharedKeys, publicKey)
+	f.device.Peers = slices.DeleteFunc(f.device.Peers, func(p wgtypes.Peer) bool {
+		return p.PublicKey == publicKey
+	})
+
+	return nil
+}
+
+func (f *FakeDevice) SwapPeer(previous wgtypes.Key, peer types.Peer) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("SwapPeer %s %s", previous.String(), peer.PublicKey.String())
+	if presharedKey, ok := f.presharedKeys[previous]; ok {
+		if _, exists := f.presharedKeys[peer.PublicKey.WG()]; !exists {
+			f.presharedKeys[peer.PublicKey.WG()] = presharedKey
+		}
+		delete(f.presharedKeys, previous)
+	}
+	f.device.Peers = slices.DeleteFunc(f.device.Peers, func(p wgtypes.Peer) bool {
+		return p.PublicKey == previous
+	})
+
+	return f.configurePeers([]types.Peer{peer})
+}
+
+func (f *FakeDevice) ReplacePeers(peers []types.Peer) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("ReplacePeers %d", len(peers))
+	f.device.Peers = nil
+
+	return f.configurePeers(peers)
+}
+
+func (f *FakeDevice) SetPresharedKey(publicKey wgtypes.Key, key *wgtypes.Key) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("SetPresharedKey %s", publicKey.String())
+	if key == nil {
+		delete(f.presharedKeys, publicKey)
+	} else {
+		f.presharedKeys[publicKey] = *key
+	}
+
+	for index, peer := range f.device.Peers {
+		if peer.PublicKey == publicKey {
+			f.device.Peers[index].PresharedKey = f.presharedKeys[publicKey]
+		}
+	}
+
+	return nil
+}
+
+func (f *FakeDevice) configurePeers(peers []types.Peer) error {
+	for _, peer := range peers {
+		config, err := peer.WGPeerConfig()
+		if err != nil {
+			continue
+		}
+
+		wgPeer := wgtypes.Peer{
+			PublicKey:    config.PublicKey,
+			PresharedKey: f.presharedKeys[config.PublicKey],
+			Endpoint:     config.Endpoint,
+			AllowedIPs:   config.AllowedIPs,
+		}
+		if config.PersistentKeepaliveInterval != nil {
+			wgPeer.PersistentKeepaliveInterval = *config.PersistentKeepaliveInterval
+		}
+
+		index := slices.IndexFunc(f.device.Peers, func(p wgtypes.Peer) bool {
+			return p.PublicKey == config.PublicKey
+		})
+		if index < 0 {
+			f.device.Peers = append(f.device.Peers, wgPeer)
+		} else {
+			if wgPeer.Endpoint == nil {
+				wgPeer.Endpoint = f.device.Peers[index].Endpoint
+			}
+			wgPeer.LastHandshakeTime = f.device.Peers[index].LastHandshakeTime
+			wgPeer.TransmitBytes = f.device.Peers[index].TransmitBytes
+			wgPeer.ReceiveBytes = f.device.Peers[index].ReceiveBytes
+			f.device.Peers[index] = wgPeer
+		}
+	}
+
+	return nil
+}
+
+func (f *FakeDevice) Probe(publicKey wgtypes.Key) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("Probe %s", publicKey.String())
+
+	return nil
+}
+
+func (f *FakeDevice) EnableForwarding(family int) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("EnableForwarding %d", family)
+	if f.forwarding == nil {
+		f.forwarding = make(map[int]bool)
+	}
+	f.forwarding[family] = true
+
+	return nil
+}
+
+func (f *FakeDevice) SetDefaultRoute(enabled bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("SetDefaultRoute %t", enabled)
+	if !f.exists {
+		return fmt.Errorf("failed to get link: link %s not found", f.name)
+	}
+	f.defaultRoute = enabled
+	f.device.FirewallMark = DeviceTuning(f.tuning, enabled).FirewallMark
+
+	return nil
+}
+
+func (f *FakeDevice) SetMasquerade(sources []net.IPNet, enabled bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("SetMasquerade %d %t", len(sources), enabled)
+	f.masquerade = nil
+	if enabled {
+		f.masquerade = slices.Clone(sources)
+	}
+
+	return nil
+}
+
+func (f *FakeDevice) Remove() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.record("Remove")
+	f.exists = false
+	f.addrs = nil
+	f.defaultRoute = false
+	f.device.Peers = nil
+
+	return nil
+}
+
+func (f *FakeDevice) Device() (*wgtypes.Device, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.exists {
+		return nil, fmt.Errorf("device %s not found", f.name)
+	}
+
+	device := f.device
+	device.Peers = slices.Clone(f.device.Peers)
+
+	return &device, nil
+}
+
+func (f *FakeDevice) Exists() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.exists
+}
+
+func (f *FakeDevice) Addrs() []net.IPNet {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return slices.Clone(f.addrs)
+}
+
+func (f *FakeDevice) Forwarding(family int) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.forwarding[family]
+}
+
+func (f *FakeDevice) DefaultRoute() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.defaultRoute
+}
+
+func (f *FakeDevice) Masquerade() []net.IPNet {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return slices.Clone(f.masquerade)
+}
+
+func (f *FakeDevice) Peers() []wgtypes.Peer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return slices.Clone(f.device.Peers)
+}
+
+func (f *FakeDevice) SetPeerStats(publicKey wgtypes.Key, lastHandshake time.Time, transmitBytes int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for index, peer := range f.device.Peers {
+		if peer.PublicKey == publicKey {
+			f.device.Peers[index].LastHandshakeTime = lastHandshake
+			f.device.Peers[index].TransmitBytes = transmitBytes
+		}
+	}
+}
+
+func (f *FakeDevice) SetPeerEndpoint(publicKey wgtypes.Key, endpoint *net.UDPAddr) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for index, peer := range f.device.Peers {
+		if peer.PublicKey == publicKey {
+			f.device.Peers[index].Endpoint = endpoint
+		}
+	}
+}
+
+func (f *FakeDevice) SetMTU(mtu int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.mtu = mtu
+}
+
+func (f *FakeDevice) Calls() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return slices.Clone(f.calls)
+}
+
+// FakeRouteManager is an in-memory RouteManager.
+type FakeRouteManager struct {
+	mutex  sync.Mutex
+	routes map[string][]net.IPNet
+}
+
+var _ RouteManager = (*FakeRouteManager)(nil)
+
+func NewFakeRouteManager() *FakeRouteManager {
+	return &FakeRouteManager{
+		routes: make(map[string][]net.IPNet),
+	}
+}
+
+func (m *FakeRouteManager) SetPeerRoutes(peer string, routes []net.IPNet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.routes[peer] = slices.Clone(routes)
+
+	return nil
+}
+
+func (m *FakeRouteManager) RemovePeerRoutes(peer string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.routes, peer)
+
+	return nil
+}
+
+func (m *FakeRouteManager) Sync(routes map[string][]net.IPNet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.routes = make(map[string][]net.IPNet, len(routes))
+	for peer, peerRoutes := range routes {
+		m.routes[peer] = slices.Clone(peerRoutes)
+	}
+
+	return nil
+}
+
+func (m *FakeRouteManager) Cleanup() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.routes = make(map[string][]net.IPNet)
+
+	return nil
+}
+
+func (m *FakeRouteManager) Routes() []net.IPNet {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	routes := []net.IPNet{}
+	for _, peerRoutes := range m.routes {
+		routes = append(routes, peerRoutes...)
+	}
+
+	return routes
+}
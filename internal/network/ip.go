@@ -2,7 +2,13 @@ package network
 
 import (
 	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
 	"net"
+
+	"github.com/vishvananda/netlink"
 )
 
 func randomBuffer(size int) []byte {
@@ -15,12 +21,17 @@ func randomBuffer(size int) []byte {
 }
 
 func RandomSubnet(network net.IPNet, prefix int) net.IPNet {
-	ones, _ := network.Mask.Size()
+	ones, bits := network.Mask.Size()
+
+	ip := network.IP.To16()
+	if bits == 8*net.IPv4len {
+		ip = network.IP.To4()
+	}
 
-	randomBuffer := randomBuffer(len(network.IP))
+	randomBuffer := randomBuffer(len(ip))
 
-	newIp := make([]byte, len(network.IP))
-	copy(newIp, network.IP)
+	newIp := make([]byte, len(ip))
+	copy(newIp, ip)
 
 	// Set the  random bits from the random buffer
 	// on bits from size to prefix
@@ -32,6 +43,94 @@ func RandomSubnet(network net.IPNet, prefix int) net.IPNet {
 
 	return net.IPNet{
 		IP:   newIp,
-		Mask: net.CIDRMask(prefix, 32),
+		Mask: net.CIDRMask(prefix, bits),
+	}
+}
+
+// Family returns the netlink address family of ip.
+func Family(ip net.IP) int {
+	if ip.To4() != nil {
+		return netlink.FAMILY_V4
+	}
+
+	return netlink.FAMILY_V6
+}
+
+// HostAddress skips the network address of IPv4 subnets.
+func HostAddress(subnet net.IPNet) net.IP {
+	ip := subnet.IP.To4()
+	ones, bits := subnet.Mask.Size()
+	if ip == nil || bits != 8*net.IPv4len || ones >= bits-1 {
+		return subnet.IP
+	}
+
+	host := make(net.IP, len(ip))
+	copy(host, ip.Mask(subnet.Mask))
+	host[len(host)-1]++
+	return host
+}
+
+var ErrNoFreeSubnet = errors.New("no free subnet")
+
+const (
+	randomSubnetAttempts = 64
+	maxEnumeratedBits    = 20
+)
+
+// FreeSubnet returns a subnet of network overlapping none of used.
+func FreeSubnet(network net.IPNet, prefix int, used []net.IPNet) (net.IPNet, error) {
+	ones, bits := network.Mask.Size()
+	if prefix < ones || prefix > bits {
+		return net.IPNet{}, fmt.Errorf("invalid subnet prefix length %d for %s", prefix, network.String())
+	}
+
+	mask := net.CIDRMask(prefix, bits)
+
+	for range randomSubnetAttempts {
+		candidate := net.IPNet{
+			IP:   RandomSubnet(network, prefix).IP.Mask(mask),
+			Mask: mask,
+		}
+		if !OverlapsAny(candidate, used) {
+			return candidate, nil
+		}
+	}
+
+	size := prefix - ones
+	if size > maxEnumeratedBits {
+		return net.IPNet{}, ErrNoFreeSubnet
+	}
+
+	count := uint64(1) << size
+	offset := binary.BigEndian.Uint64(randomBuffer(8)) % count
+	base := new(big.Int).SetBytes(network.IP.Mask(network.Mask))
+	for i := range count {
+		index := new(big.Int).SetUint64((offset + i) % count)
+		ip := index.Lsh(index, uint(bits-prefix)).Or(index, base).FillBytes(make([]byte, bits/8))
+
+		candidate := net.IPNet{
+			IP:   ip,
+			Mask: mask,
+		}
+		if !OverlapsAny(candidate, used) {
+			return candidate, nil
+		}
+	}
+
+	return net.IPNet{}, ErrNoFreeSubnet
+}
+
+// Overlaps reports whether a and b share at least one address.
+func Overlaps(a, b net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func OverlapsAny(ipnet net.IPNet, others []net.IPNet) bool {
+	for _, other := range others {
+		if Overlaps(ipnet, other) {
+			return true
+		}
 	}
+
+	return false
 }
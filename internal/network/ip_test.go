@@ -0,0 +1,91 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestRandomSubnet(t *testing.T) {
+	tests := []struct {
+		mesh   string
+		prefix int
+		len    int
+	}{
+		{"fd10::/16", 48, net.IPv6len},
+		{"10.0.0.0/8", 24, net.IPv4len},
+		{"100.64.0.0/10", 32, net.IPv4len},
+	}
+
+	for _, test := range tests {
+		_, mesh, _ := net.ParseCIDR(test.mesh)
+
+		for range 100 {
+			subnet := RandomSubnet(*mesh, test.prefix)
+
+			if len(subnet.IP) != test.len {
+				t.Fatalf("%s: expected %d bytes address, got %d", test.mesh, test.len, len(subnet.IP))
+			}
+			if ones, bits := subnet.Mask.Size(); ones != test.prefix || bits != test.len*8 {
+				t.Fatalf("%s: unexpected mask %s", test.mesh, subnet.Mask.String())
+			}
+			if !mesh.Contains(subnet.IP) {
+				t.Fatalf("%s: %s is not in the mesh", test.mesh, subnet.String())
+			}
+			if !subnet.IP.Equal(subnet.IP.Mask(subnet.Mask)) {
+				t.Fatalf("%s: %s has host bits set", test.mesh, subnet.String())
+			}
+		}
+	}
+}
+
+func TestFreeSubnet(t *testing.T) {
+	_, mesh, _ := net.ParseCIDR("10.0.0.0/22")
+
+	var used []net.IPNet
+	for range 4 {
+		subnet, err := FreeSubnet(*mesh, 24, used)
+		if err != nil {
+			t.Fatalf("failed to find a free subnet: %v", err)
+		}
+		if OverlapsAny(subnet, used) {
+			t.Fatalf("%s overlaps a used subnet", subnet.String())
+		}
+		used = append(used, subnet)
+	}
+
+	_, err := FreeSubnet(*mesh, 24, used)
+	if !errors.Is(err, ErrNoFreeSubnet) {
+		t.Fatalf("expected ErrNoFreeSubnet, got %v", err)
+	}
+
+	_, err = FreeSubnet(*mesh, 20, nil)
+	if err == nil {
+		t.Fatal("expected an error for a prefix shorter than the mesh")
+	}
+}
+
+func TestHostAddress(t *testing.T) {
+	tests := []struct {
+		subnet string
+		host   string
+	}{
+		{"10.1.2.0/24", "10.1.2.1"},
+		{"100.64.0.4/30", "100.64.0.5"},
+		{"100.64.0.4/31", "100.64.0.4"},
+		{"100.64.0.7/32", "100.64.0.7"},
+		{"fd10:1::/48", "fd10:1::"},
+	}
+
+	for _, test := range tests {
+		_, subnet, _ := net.ParseCIDR(test.subnet)
+
+		host := HostAddress(*subnet)
+		if !host.Equal(net.ParseIP(test.host)) {
+			t.Fatalf("%s: expected %s, got %s", test.subnet, test.host, host)
+		}
+		if !subnet.Contains(host) {
+			t.Fatalf("%s: %s is not in the subnet", test.subnet, host)
+		}
+	}
+}
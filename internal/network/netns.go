@@ -0,0 +1,83 @@
+package network
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// Namespace is the network namespace of the WireGuard interface.
+type Namespace struct {
+	name    string
+	handle  netns.NsHandle
+	netlink *netlink.Handle
+}
+
+// OpenNamespace opens a namespace under /var/run/netns or at a path, or the
+// namespace of the agent if name is empty.
+func OpenNamespace(name string) (*Namespace, error) {
+	if name == "" {
+		return &Namespace{
+			handle:  netns.None(),
+			netlink: &netlink.Handle{},
+		}, nil
+	}
+
+	var handle netns.NsHandle
+	var err error
+	if strings.HasPrefix(name, "/") {
+		handle, err = netns.GetFromPath(name)
+	} else {
+		handle, err = netns.GetFromName(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open network namespace %s: %w", name, err)
+	}
+
+	h, err := netlink.NewHandleAt(handle)
+	if err != nil {
+		handle.Close()
+		return nil, fmt.Errorf("failed to open netlink handle in network namespace %s: %w", name, err)
+	}
+
+	return &Namespace{
+		name:    name,
+		handle:  handle,
+		netlink: h,
+	}, nil
+}
+
+func (n *Namespace) isolated() bool {
+	return n.handle.IsOpen()
+}
+
+// do runs fn with the current thread in the namespace.
+func (n *Namespace) do(fn func() error) error {
+	if !n.isolated() {
+		return fn()
+	}
+
+	runtime.LockOSThread()
+	origin, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("failed to get current network namespace: %w", err)
+	}
+	defer origin.Close()
+
+	if err := netns.Set(n.handle); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("failed to enter network namespace %s: %w", n.name, err)
+	}
+	defer func() {
+		// The thread stays locked and exits with the goroutine.
+		if err := netns.Set(origin); err == nil {
+			runtime.UnlockOSThread()
+		}
+	}()
+
+	return fn()
+}
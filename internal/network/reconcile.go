@@ -0,0 +1,143 @@
+package network
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// Drift describes the differences between the device and the desired state.
+type Drift struct {
+	PrivateKey    bool
+	ListenPort    bool
+	Tuning        bool
+	MissingPeers  []wgtypes.Key
+	UnknownPeers  []wgtypes.Key
+	ModifiedPeers []wgtypes.Key
+}
+
+func (d *Drift) Empty() bool {
+	return !d.PrivateKey && !d.ListenPort && !d.Tuning && len(d.MissingPeers) == 0 && len(d.UnknownPeers) == 0 && len(d.ModifiedPeers) == 0
+}
+
+// Reconcile corrects the drift of the device unless dryRun is set.
+// defaultRoute is set while the default route goes through the interface.
+func Reconcile(d Device, privateKey wgtypes.Key, port int, tuning types.Tuning, defaultRoute bool, peers []types.Peer, dryRun bool) (Drift, error) {
+	device, err := d.Device()
+	if err != nil {
+		return Drift{}, fmt.Errorf("failed to read device: %w", err)
+	}
+
+	actualTuning, err := d.Tuning()
+	if err != nil {
+		return Drift{}, fmt.Errorf("failed to read device tuning: %w", err)
+	}
+
+	drift := Drift{
+		PrivateKey: device.PrivateKey != privateKey,
+		ListenPort: device.ListenPort != port,
+		Tuning:     !tuningMatches(actualTuning, DeviceTuning(tuning, defaultRoute)),
+	}
+
+	current := make(map[wgtypes.Key]wgtypes.Peer, len(device.Peers))
+	for _, peer := range device.Peers {
+		current[peer.PublicKey] = peer
+	}
+
+	desired := make(map[wgtypes.Key]struct{}, len(peers))
+	corrections := []types.Peer{}
+	for _, peer := range peers {
+		config, err := peer.WGPeerConfig()
+		if err != nil {
+			slog.Error("failed to get peer config", "error", err, "peer_name", peer.Name, "public_key", peer.PublicKey.String())
+			continue
+		}
+		desired[config.PublicKey] = struct{}{}
+
+		actual, ok := current[config.PublicKey]
+		if !ok {
+			drift.MissingPeers = append(drift.MissingPeers, config.PublicKey)
+		} else if !peerMatches(actual, config) {
+			drift.ModifiedPeers = append(drift.ModifiedPeers, config.PublicKey)
+		} else {
+			continue
+		}
+
+		corrections = append(corrections, peer)
+	}
+
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			drift.UnknownPeers = append(drift.UnknownPeers, key)
+		}
+	}
+
+	if dryRun || drift.Empty() {
+		return drift, nil
+	}
+
+	if drift.PrivateKey || drift.ListenPort {
+		if err := d.InitConfig(); err != nil {
+			return drift, fmt.Errorf("failed to correct device config: %w", err)
+		}
+	}
+
+	if drift.Tuning {
+		if err := d.SetTuning(tuning); err != nil {
+			return drift, fmt.Errorf("failed to correct device tuning: %w", err)
+		}
+	}
+
+	for _, key := range drift.UnknownPeers {
+		if err := d.RemovePeer(key); err != nil {
+			return drift, fmt.Errorf("failed to remove unknown peer: %w", err)
+		}
+	}
+
+	for _, peer := range corrections {
+		if err := d.AddPeer(peer); err != nil {
+			return drift, fmt.Errorf("failed to correct peer: %w", err)
+		}
+	}
+
+	return drift, nil
+}
+
+// tuningMatches ignores the unset MTU and queue length.
+func tuningMatches(actual types.Tuning, desired types.Tuning) bool {
+	if desired.MTU != 0 && actual.MTU != desired.MTU {
+		return false
+	}
+	if desired.TxQueueLen != 0 && actual.TxQueueLen != desired.TxQueueLen {
+		return false
+	}
+
+	return actual.FirewallMark == desired.FirewallMark
+}
+
+func peerMatches(actual wgtypes.Peer, desired wgtypes.PeerConfig) bool {
+	if desired.PersistentKeepaliveInterval != nil && actual.PersistentKeepaliveInterval != *desired.PersistentKeepaliveInterval {
+		return false
+	}
+
+	if desired.Endpoint != nil {
+		if actual.Endpoint == nil || !actual.Endpoint.IP.Equal(desired.Endpoint.IP) || actual.Endpoint.Port != desired.Endpoint.Port {
+			return false
+		}
+	}
+
+	return slices.Equal(sortedIPNets(actual.AllowedIPs), sortedIPNets(desired.AllowedIPs))
+}
+
+func sortedIPNets(ipnets []net.IPNet) []string {
+	s := make([]string, 0, len(ipnets))
+	for _, ipnet := range ipnets {
+		s = append(s, ipnet.String())
+	}
+	slices.Sort(s)
+	return s
+}
@@ -0,0 +1,240 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// routeProtocol tags the routes installed by ikto.
+const routeProtocol netlink.RouteProtocol = 105
+
+// RouteManager installs the routes to the peers through the interface.
+type RouteManager interface {
+	SetPeerRoutes(peer string, routes []net.IPNet) error
+	RemovePeerRoutes(peer string) error
+	// Sync also removes the routes left over by a previous run.
+	Sync(routes map[string][]net.IPNet) error
+	Cleanup() error
+}
+
+var _ RouteManager = (*NetlinkRouteManager)(nil)
+
+// NetlinkRouteManager installs the routes in a routing table of the kernel.
+type NetlinkRouteManager struct {
+	mutex  sync.Mutex
+	name   string
+	ns     *Namespace
+	table  int
+	metric int
+	routes map[string][]net.IPNet
+}
+
+// NewRouteManager installs the routes in table, the main table if zero.
+func NewRouteManager(name string, ns *Namespace, table int, metric int) *NetlinkRouteManager {
+	return &NetlinkRouteManager{
+		name:   name,
+		ns:     ns,
+		table:  table,
+		metric: metric,
+		routes: make(map[string][]net.IPNet),
+	}
+}
+
+func (m *NetlinkRouteManager) route(link netlink.Link, dst net.IPNet) *netlink.Route {
+	return &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       &dst,
+		Table:     m.table,
+		Priority:  m.metric,
+		Protocol:  routeProtocol,
+	}
+}
+
+func (m *NetlinkRouteManager) SetPeerRoutes(peer string, routes []net.IPNet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	link, err := m.ns.netlink.LinkByName(m.name)
+	if err != nil {
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	return m.setPeerRoutes(link, peer, routes)
+}
+
+func (m *NetlinkRouteManager) setPeerRoutes(link netlink.Link, peer string, routes []net.IPNet) error {
+	for _, route := range routes {
+		if err := m.ns.netlink.RouteReplace(m.route(link, route)); err != nil {
+			return fmt.Errorf("failed to add route %s: %w", route.String(), err)
+		}
+	}
+
+	for _, previous := range m.routes[peer] {
+		if containsIPNet(routes, previous) || m.usedByOther(peer, previous) {
+			continue
+		}
+
+		if err := m.deleteRoute(link, previous); err != nil {
+			slog.Error("failed to delete route", "error", err, "route", previous.String())
+		}
+	}
+
+	m.routes[peer] = routes
+
+	return nil
+}
+
+func (m *NetlinkRouteManager) RemovePeerRoutes(peer string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	link, err := m.ns.netlink.LinkByName(m.name)
+	if err != nil {
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	for _, route := range m.routes[peer] {
+		if m.usedByOther(peer, route) {
+			continue
+		}
+		if err := m.deleteRoute(link, route); err != nil {
+			return err
+		}
+	}
+	delete(m.routes, peer)
+
+	return nil
+}
+
+func (m *NetlinkRouteManager) Sync(routes map[string][]net.IPNet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	link, err := m.ns.netlink.LinkByName(m.name)
+	if err != nil {
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	desired := []net.IPNet{}
+	for peer, peerRoutes := range routes {
+		if err := m.setPeerRoutes(link, peer, peerRoutes); err != nil {
+			return err
+		}
+		desired = append(desired, peerRoutes...)
+	}
+
+	for peer := range m.routes {
+		if _, ok := routes[peer]; !ok {
+			delete(m.routes, peer)
+		}
+	}
+
+	return m.deleteRoutes(link, func(dst net.IPNet) bool {
+		return !containsIPNet(desired, dst)
+	})
+}
+
+func (m *NetlinkRouteManager) Cleanup() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.routes = make(map[string][]net.IPNet)
+
+	link, err := m.ns.netlink.LinkByName(m.name)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	return m.deleteRoutes(link, func(net.IPNet) bool {
+		return true
+	})
+}
+
+func (m *NetlinkRouteManager) deleteRoutes(link netlink.Link, match func(dst net.IPNet) bool) error {
+	table := m.table
+	if table == 0 {
+		table = mainRouteTable
+	}
+
+	current, err := m.ns.netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Table:     table,
+		Protocol:  routeProtocol,
+	}, netlink.RT_FILTER_OIF|netlink.RT_FILTER_TABLE|netlink.RT_FILTER_PROTOCOL)
+	if err != nil {
+		return fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	for _, route := range current {
+		if route.Dst == nil || !match(*route.Dst) {
+			continue
+		}
+
+		if err := m.ns.netlink.RouteDel(&route); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("failed to delete route %s: %w", route.Dst.String(), err)
+		}
+	}
+
+	return nil
+}
+
+func (m *NetlinkRouteManager) deleteRoute(link netlink.Link, dst net.IPNet) error {
+	err := m.ns.netlink.RouteDel(m.route(link, dst))
+	if err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to delete route %s: %w", dst.String(), err)
+	}
+
+	return nil
+}
+
+// usedByOther reports whether route moved to another peer.
+func (m *NetlinkRouteManager) usedByOther(peer string, route net.IPNet) bool {
+	for other, routes := range m.routes {
+		if other != peer && containsIPNet(routes, route) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsIPNet(ipnets []net.IPNet, ipnet net.IPNet) bool {
+	for _, candidate := range ipnets {
+		if candidate.String() == ipnet.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+var forwardingSysctls = map[int]string{
+	netlink.FAMILY_V4: "/proc/sys/net/ipv4/ip_forward",
+	netlink.FAMILY_V6: "/proc/sys/net/ipv6/conf/all/forwarding",
+}
+
+func (d *WGDevice) EnableForwarding(family int) error {
+	path, ok := forwardingSysctls[family]
+	if !ok {
+		return fmt.Errorf("unsupported address family %d", family)
+	}
+
+	err := d.ns.do(func() error {
+		return os.WriteFile(path, []byte("1"), 0644)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable forwarding: %w", err)
+	}
+
+	return nil
+}
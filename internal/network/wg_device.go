@@ -1,9 +1,14 @@
 package network
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/valyentdev/ikto/pkg/types"
 	"github.com/vishvananda/netlink"
@@ -15,35 +20,45 @@ type WGDevice struct {
 	name       string
 	port       int
 	wg         *wgctrl.Client
+	ns         *Namespace
 	privateKey wgtypes.Key
+	tuning     types.Tuning
+	// defaultRoute is set while the default route goes through the device.
+	defaultRoute bool
+
+	presharedKeysMutex sync.Mutex
+	presharedKeys      map[wgtypes.Key]wgtypes.Key
 }
 
-func New(name string, port int, privateKey wgtypes.Key) (*WGDevice, error) {
-	client, err := wgctrl.New()
+func New(name string, port int, privateKey wgtypes.Key, ns *Namespace) (*WGDevice, error) {
+	var client *wgctrl.Client
+	err := ns.do(func() error {
+		var err error
+		client, err = wgctrl.New()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create wgctrl client: %w", err)
 	}
 
 	return &WGDevice{
 		wg:         client,
+		ns:         ns,
 		name:       name,
 		port:       port,
 		privateKey: privateKey,
+
+		presharedKeys: make(map[wgtypes.Key]wgtypes.Key),
 	}, nil
 }
 
 func (d *WGDevice) Ensure() error {
-	link, err := netlink.LinkByName(d.name)
+	link, err := d.ns.netlink.LinkByName(d.name)
 	if err != nil {
 		switch err.(type) {
 		case netlink.LinkNotFoundError:
-			link = &netlink.Wireguard{
-				LinkAttrs: netlink.LinkAttrs{
-					Name: d.name,
-				},
-			}
-
-			if err := netlink.LinkAdd(link); err != nil {
+			link, err = d.create()
+			if err != nil {
 				return err
 			}
 		default:
@@ -51,15 +66,87 @@ func (d *WGDevice) Ensure() error {
 		}
 	}
 
-	if err := netlink.LinkSetUp(link); err != nil {
+	if err := d.ns.netlink.LinkSetUp(link); err != nil {
 		return fmt.Errorf("failed to set link up: %w", err)
 	}
 
 	return nil
 }
 
-func (d WGDevice) SetAddr(ipnet net.IPNet) error {
-	link, err := netlink.LinkByName(d.name)
+// create adds the interface in the namespace of the agent, where its socket
+// stays, and moves it to the namespace of the device.
+func (d *WGDevice) create() (netlink.Link, error) {
+	var link netlink.Link = &netlink.Wireguard{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: d.name,
+		},
+	}
+
+	err := netlink.LinkAdd(link)
+	if err != nil && (!d.ns.isolated() || !errors.Is(err, syscall.EEXIST)) {
+		return nil, err
+	}
+	if !d.ns.isolated() {
+		return link, nil
+	}
+
+	if err != nil {
+		link, err = netlink.LinkByName(d.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get link: %w", err)
+		}
+		if err := d.adoptable(link); err != nil {
+			return nil, fmt.Errorf("link %s exists in the namespace of the agent: %w", d.name, err)
+		}
+	}
+
+	if err := netlink.LinkSetNsFd(link, int(d.ns.handle)); err != nil {
+		return nil, fmt.Errorf("failed to move link to network namespace %s: %w", d.ns.name, err)
+	}
+
+	link, err = d.ns.netlink.LinkByName(d.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	return link, nil
+}
+
+// adoptable checks that link was left down and unconfigured by an
+// interrupted move.
+func (d *WGDevice) adoptable(link netlink.Link) error {
+	if link.Type() != "wireguard" {
+		return errors.New("not a wireguard interface")
+	}
+	if link.Attrs().Flags&net.FlagUp != 0 {
+		return errors.New("interface is up")
+	}
+
+	client, err := wgctrl.New()
+	if err != nil {
+		return fmt.Errorf("failed to create wgctrl client: %w", err)
+	}
+	defer client.Close()
+
+	device, err := client.Device(d.name)
+	if err != nil {
+		return fmt.Errorf("failed to get device: %w", err)
+	}
+	if len(device.Peers) > 0 {
+		return errors.New("interface has peers")
+	}
+	if device.PrivateKey != (wgtypes.Key{}) && device.PrivateKey != d.privateKey {
+		return errors.New("interface has another private key")
+	}
+	if device.ListenPort != 0 && device.ListenPort != d.port {
+		return fmt.Errorf("interface listens on port %d", device.ListenPort)
+	}
+
+	return nil
+}
+
+func (d *WGDevice) SetAddr(ipnet net.IPNet) error {
+	link, err := d.ns.netlink.LinkByName(d.name)
 	if err != nil {
 		return fmt.Errorf("failed to get link: %w", err)
 	}
@@ -68,17 +155,17 @@ func (d WGDevice) SetAddr(ipnet net.IPNet) error {
 		IPNet: &ipnet,
 	}
 
-	if err := netlink.AddrReplace(link, newAddr); err != nil {
+	if err := d.ns.netlink.AddrReplace(link, newAddr); err != nil {
 		return fmt.Errorf("failed to add addr: %w", err)
 	}
-	list, err := netlink.AddrList(link, netlink.FAMILY_V6)
+	list, err := d.ns.netlink.AddrList(link, Family(ipnet.IP))
 	if err != nil {
 		return fmt.Errorf("failed to list addrs: %w", err)
 	}
 
 	for _, addr := range list {
 		if !addr.Equal(*newAddr) {
-			if err := netlink.AddrDel(link, &addr); err != nil {
+			if err := d.ns.netlink.AddrDel(link, &addr); err != nil {
 				slog.Error("failed to delete addr", "error", err)
 			}
 		}
@@ -88,14 +175,58 @@ func (d WGDevice) SetAddr(ipnet net.IPNet) error {
 }
 
 func (m *WGDevice) InitConfig() error {
+	firewallMark := DeviceTuning(m.tuning, m.defaultRoute).FirewallMark
+
 	return m.wg.ConfigureDevice(m.name, wgtypes.Config{
-		PrivateKey: &m.privateKey,
-		ListenPort: &m.port,
+		PrivateKey:   &m.privateKey,
+		ListenPort:   &m.port,
+		FirewallMark: &firewallMark,
 	})
 }
 
+func (m *WGDevice) SetTuning(tuning types.Tuning) error {
+	m.tuning = tuning
+
+	link, err := m.ns.netlink.LinkByName(m.name)
+	if err != nil {
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+
+	if tuning.MTU != 0 && link.Attrs().MTU != tuning.MTU {
+		if err := m.ns.netlink.LinkSetMTU(link, tuning.MTU); err != nil {
+			return fmt.Errorf("failed to set mtu: %w", err)
+		}
+	}
+
+	if tuning.TxQueueLen != 0 && link.Attrs().TxQLen != tuning.TxQueueLen {
+		if err := m.ns.netlink.LinkSetTxQLen(link, tuning.TxQueueLen); err != nil {
+			return fmt.Errorf("failed to set txqueuelen: %w", err)
+		}
+	}
+
+	return m.InitConfig()
+}
+
+func (m *WGDevice) Tuning() (types.Tuning, error) {
+	link, err := m.ns.netlink.LinkByName(m.name)
+	if err != nil {
+		return types.Tuning{}, fmt.Errorf("failed to get link: %w", err)
+	}
+
+	device, err := m.wg.Device(m.name)
+	if err != nil {
+		return types.Tuning{}, err
+	}
+
+	return types.Tuning{
+		MTU:          link.Attrs().MTU,
+		FirewallMark: device.FirewallMark,
+		TxQueueLen:   link.Attrs().TxQLen,
+	}, nil
+}
+
 func (m *WGDevice) Remove() error {
-	link, err := netlink.LinkByName(m.name)
+	link, err := m.ns.netlink.LinkByName(m.name)
 	if err != nil {
 		switch err.(type) {
 		case netlink.LinkNotFoundError:
@@ -105,7 +236,7 @@ func (m *WGDevice) Remove() error {
 		}
 	}
 
-	if err := netlink.LinkDel(link); err != nil {
+	if err := m.ns.netlink.LinkDel(link); err != nil {
 		return fmt.Errorf("failed to delete link: %w", err)
 	}
 
@@ -113,7 +244,17 @@ func (m *WGDevice) Remove() error {
 
 }
 
+func (m *WGDevice) SetPrivateKey(privateKey wgtypes.Key) error {
+	m.privateKey = privateKey
+
+	return m.InitConfig()
+}
+
 func (m *WGDevice) RemovePeer(publicKey wgtypes.Key) error {
+	m.presharedKeysMutex.Lock()
+	delete(m.presharedKeys, publicKey)
+	m.presharedKeysMutex.Unlock()
+
 	return m.wg.ConfigureDevice(m.name, wgtypes.Config{
 		Peers: []wgtypes.PeerConfig{
 			{
@@ -132,6 +273,36 @@ func (m *WGDevice) ReplacePeers(peers []types.Peer) error {
 	return m.configurePeers(peers, true)
 }
 
+func (m *WGDevice) SwapPeer(previous wgtypes.Key, peer types.Peer) error {
+	peerConfig, err := peer.WGPeerConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get peer config: %w", err)
+	}
+	peerConfig.ReplaceAllowedIPs = true
+
+	m.presharedKeysMutex.Lock()
+	if presharedKey, ok := m.presharedKeys[previous]; ok {
+		if _, exists := m.presharedKeys[peerConfig.PublicKey]; !exists {
+			m.presharedKeys[peerConfig.PublicKey] = presharedKey
+		}
+		delete(m.presharedKeys, previous)
+	}
+	if presharedKey, ok := m.presharedKeys[peerConfig.PublicKey]; ok {
+		peerConfig.PresharedKey = &presharedKey
+	}
+	m.presharedKeysMutex.Unlock()
+
+	return m.wg.ConfigureDevice(m.name, wgtypes.Config{
+		Peers: []wgtypes.PeerConfig{
+			{
+				PublicKey: previous,
+				Remove:    true,
+			},
+			peerConfig,
+		},
+	})
+}
+
 func (m *WGDevice) configurePeers(peers []types.Peer, replacePeers bool) error {
 	peerConfigs := make([]wgtypes.PeerConfig, 0, len(peers))
 	for _, member := range peers {
@@ -141,6 +312,10 @@ func (m *WGDevice) configurePeers(peers []types.Peer, replacePeers bool) error {
 			continue
 		}
 
+		peerConfig.ReplaceAllowedIPs = true
+		if presharedKey, ok := m.presharedKey(peerConfig.PublicKey); ok {
+			peerConfig.PresharedKey = &presharedKey
+		}
 		peerConfigs = append(peerConfigs, peerConfig)
 	}
 
@@ -149,3 +324,78 @@ func (m *WGDevice) configurePeers(peers []types.Peer, replacePeers bool) error {
 		ReplacePeers: replacePeers,
 	})
 }
+
+func (m *WGDevice) presharedKey(publicKey wgtypes.Key) (wgtypes.Key, bool) {
+	m.presharedKeysMutex.Lock()
+	defer m.presharedKeysMutex.Unlock()
+
+	key, ok := m.presharedKeys[publicKey]
+	return key, ok
+}
+
+func (m *WGDevice) SetPresharedKey(publicKey wgtypes.Key, key *wgtypes.Key) error {
+	m.presharedKeysMutex.Lock()
+	if key == nil {
+		delete(m.presharedKeys, publicKey)
+	} else {
+		m.presharedKeys[publicKey] = *key
+	}
+	m.presharedKeysMutex.Unlock()
+
+	presharedKey := wgtypes.Key{}
+	if key != nil {
+		presharedKey = *key
+	}
+
+	return m.wg.ConfigureDevice(m.name, wgtypes.Config{
+		Peers: []wgtypes.PeerConfig{
+			{
+				PublicKey:    publicKey,
+				UpdateOnly:   true,
+				PresharedKey: &presharedKey,
+			},
+		},
+	})
+}
+
+// Probe relies on the kernel sending a keepalive as soon as it is enabled.
+func (m *WGDevice) Probe(publicKey wgtypes.Key) error {
+	device, err := m.wg.Device(m.name)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(device.Peers, func(p wgtypes.Peer) bool {
+		return p.PublicKey == publicKey
+	})
+	if index < 0 {
+		return fmt.Errorf("unknown peer %s", publicKey.String())
+	}
+
+	current := device.Peers[index].PersistentKeepaliveInterval
+	intervals := []time.Duration{0, types.NATKeepaliveInterval, current}
+	if current != 0 {
+		intervals = []time.Duration{0, current}
+	}
+
+	for _, interval := range intervals {
+		err := m.wg.ConfigureDevice(m.name, wgtypes.Config{
+			Peers: []wgtypes.PeerConfig{
+				{
+					PublicKey:                   publicKey,
+					UpdateOnly:                  true,
+					PersistentKeepaliveInterval: &interval,
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *WGDevice) Device() (*wgtypes.Device, error) {
+	return d.wg.Device(d.name)
+}
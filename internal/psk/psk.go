@@ -0,0 +1,217 @@
+// Package psk negotiates the WireGuard preshared key of a pair of peers with
+// ML-KEM, authenticated by their static keys.
+package psk
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/mlkem"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+const MaxClockSkew = 2 * time.Minute
+
+var (
+	ErrInvalidMAC   = errors.New("invalid message authentication code")
+	ErrStaleRequest = errors.New("stale preshared key request")
+)
+
+// Request is sent by the initiator to the responder.
+type Request struct {
+	Initiator        types.PublicKey `json:"initiator"`
+	Responder        types.PublicKey `json:"responder"`
+	Timestamp        int64           `json:"timestamp"`
+	EncapsulationKey []byte          `json:"encapsulation_key"`
+	MAC              []byte          `json:"mac"`
+}
+
+// Response is sent back by the responder.
+type Response struct {
+	Ciphertext []byte `json:"ciphertext"`
+	MAC        []byte `json:"mac"`
+}
+
+// Confirmation proves to the responder that the initiator derived the key,
+// so that the responder applies it.
+type Confirmation struct {
+	Initiator types.PublicKey `json:"initiator"`
+	MAC       []byte          `json:"mac"`
+}
+
+type Initiator struct {
+	request         Request
+	decapsulation   *mlkem.DecapsulationKey768
+	staticSecret    []byte
+	authenticateKey []byte
+}
+
+// Initiate starts an exchange with the peer whose public key is peer.
+func Initiate(privateKey wgtypes.Key, peer wgtypes.Key, now time.Time) (*Initiator, Request, error) {
+	staticSecret, authenticateKey, err := staticKeys(privateKey, peer)
+	if err != nil {
+		return nil, Request{}, err
+	}
+
+	decapsulation, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, Request{}, fmt.Errorf("failed to generate kem key: %w", err)
+	}
+
+	request := Request{
+		Initiator:        types.PublicKey(privateKey.PublicKey()),
+		Responder:        types.PublicKey(peer),
+		Timestamp:        now.UnixNano(),
+		EncapsulationKey: decapsulation.EncapsulationKey().Bytes(),
+	}
+	request.MAC = request.mac(authenticateKey)
+
+	return &Initiator{
+		request:         request,
+		decapsulation:   decapsulation,
+		staticSecret:    staticSecret,
+		authenticateKey: authenticateKey,
+	}, request, nil
+}
+
+func (i *Initiator) Finish(response Response) (wgtypes.Key, error) {
+	if !hmac.Equal(response.MAC, response.mac(i.authenticateKey, i.request.MAC)) {
+		return wgtypes.Key{}, ErrInvalidMAC
+	}
+
+	kemSecret, err := i.decapsulation.Decapsulate(response.Ciphertext)
+	if err != nil {
+		return wgtypes.Key{}, fmt.Errorf("failed to decapsulate: %w", err)
+	}
+
+	return derive(kemSecret, i.staticSecret, i.request, response)
+}
+
+// Confirm returns the confirmation of key, as returned by Finish.
+func (i *Initiator) Confirm(key wgtypes.Key) Confirmation {
+	return Confirmation{
+		Initiator: i.request.Initiator,
+		MAC:       confirmationMAC(key, i.request.MAC),
+	}
+}
+
+// VerifyConfirmation checks that confirmation was computed from key, the
+// preshared key negotiated by request.
+func VerifyConfirmation(key wgtypes.Key, request Request, confirmation Confirmation) error {
+	if confirmation.Initiator != request.Initiator || !hmac.Equal(confirmation.MAC, confirmationMAC(key, request.MAC)) {
+		return ErrInvalidMAC
+	}
+
+	return nil
+}
+
+// Respond returns the response and the preshared key. The caller must
+// reject the replayed requests.
+func Respond(privateKey wgtypes.Key, request Request, now time.Time) (Response, wgtypes.Key, error) {
+	if request.Responder.WG() != privateKey.PublicKey() {
+		return Response{}, wgtypes.Key{}, fmt.Errorf("request is not addressed to the local node")
+	}
+
+	staticSecret, authenticateKey, err := staticKeys(privateKey, request.Initiator.WG())
+	if err != nil {
+		return Response{}, wgtypes.Key{}, err
+	}
+
+	if !hmac.Equal(request.MAC, request.mac(authenticateKey)) {
+		return Response{}, wgtypes.Key{}, ErrInvalidMAC
+	}
+
+	if skew := now.Sub(time.Unix(0, request.Timestamp)).Abs(); skew > MaxClockSkew {
+		return Response{}, wgtypes.Key{}, fmt.Errorf("%w: clock skew of %s", ErrStaleRequest, skew)
+	}
+
+	encapsulation, err := mlkem.NewEncapsulationKey768(request.EncapsulationKey)
+	if err != nil {
+		return Response{}, wgtypes.Key{}, fmt.Errorf("invalid encapsulation key: %w", err)
+	}
+
+	kemSecret, ciphertext := encapsulation.Encapsulate()
+
+	response := Response{
+		Ciphertext: ciphertext,
+	}
+	response.MAC = response.mac(authenticateKey, request.MAC)
+
+	key, err := derive(kemSecret, staticSecret, request, response)
+	if err != nil {
+		return Response{}, wgtypes.Key{}, err
+	}
+
+	return response, key, nil
+}
+
+func staticKeys(privateKey wgtypes.Key, peer wgtypes.Key) ([]byte, []byte, error) {
+	private, err := ecdh.X25519().NewPrivateKey(privateKey[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	public, err := ecdh.X25519().NewPublicKey(peer[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+
+	staticSecret, err := private.ECDH(public)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute static secret: %w", err)
+	}
+
+	authenticateKey, err := hkdf.Key(sha256.New, staticSecret, nil, "ikto psk authentication", 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return staticSecret, authenticateKey, nil
+}
+
+func derive(kemSecret []byte, staticSecret []byte, request Request, response Response) (wgtypes.Key, error) {
+	transcript := sha256.New()
+	transcript.Write(request.MAC)
+	transcript.Write(response.MAC)
+
+	secret := append(bytes.Clone(kemSecret), staticSecret...)
+	derived, err := hkdf.Key(sha256.New, secret, transcript.Sum(nil), "ikto psk", wgtypes.KeyLen)
+	if err != nil {
+		return wgtypes.Key{}, err
+	}
+
+	return wgtypes.NewKey(derived)
+}
+
+func (r *Request) mac(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("request"))
+	mac.Write(r.Initiator[:])
+	mac.Write(r.Responder[:])
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(r.Timestamp)))
+	mac.Write(r.EncapsulationKey)
+	return mac.Sum(nil)
+}
+
+func confirmationMAC(key wgtypes.Key, requestMAC []byte) []byte {
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte("confirmation"))
+	mac.Write(requestMAC)
+	return mac.Sum(nil)
+}
+
+func (r *Response) mac(key []byte, requestMAC []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("response"))
+	mac.Write(requestMAC)
+	mac.Write(r.Ciphertext)
+	return mac.Sum(nil)
+}
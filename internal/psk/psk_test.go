@@ -0,0 +1,101 @@
+package psk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func generateKey(t *testing.T) wgtypes.Key {
+	t.Helper()
+
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func TestExchange(t *testing.T) {
+	initiatorKey, responderKey := generateKey(t), generateKey(t)
+	now := time.Now()
+
+	initiator, request, err := Initiate(initiatorKey, responderKey.PublicKey(), now)
+	if err != nil {
+		t.Fatalf("failed to initiate: %v", err)
+	}
+
+	response, responderPSK, err := Respond(responderKey, request, now)
+	if err != nil {
+		t.Fatalf("failed to respond: %v", err)
+	}
+
+	initiatorPSK, err := initiator.Finish(response)
+	if err != nil {
+		t.Fatalf("failed to finish: %v", err)
+	}
+
+	if initiatorPSK != responderPSK {
+		t.Fatalf("preshared keys differ")
+	}
+
+	confirmation := initiator.Confirm(initiatorPSK)
+	if err := VerifyConfirmation(responderPSK, request, confirmation); err != nil {
+		t.Fatalf("failed to verify confirmation: %v", err)
+	}
+
+	_, otherRequest, err := Initiate(initiatorKey, responderKey.PublicKey(), now)
+	if err != nil {
+		t.Fatalf("failed to initiate: %v", err)
+	}
+	_, otherPSK, err := Respond(responderKey, otherRequest, now)
+	if err != nil {
+		t.Fatalf("failed to respond: %v", err)
+	}
+	if otherPSK == responderPSK {
+		t.Fatalf("expected a fresh preshared key for every exchange")
+	}
+	if err := VerifyConfirmation(otherPSK, otherRequest, confirmation); !errors.Is(err, ErrInvalidMAC) {
+		t.Fatalf("expected ErrInvalidMAC for the confirmation of another exchange, got %v", err)
+	}
+}
+
+func TestRejectsForgedMessages(t *testing.T) {
+	initiatorKey, responderKey, attackerKey := generateKey(t), generateKey(t), generateKey(t)
+	now := time.Now()
+
+	initiator, request, err := Initiate(initiatorKey, responderKey.PublicKey(), now)
+	if err != nil {
+		t.Fatalf("failed to initiate: %v", err)
+	}
+
+	tampered := request
+	tampered.Timestamp++
+	if _, _, err := Respond(responderKey, tampered, now); !errors.Is(err, ErrInvalidMAC) {
+		t.Fatalf("expected ErrInvalidMAC for a tampered request, got %v", err)
+	}
+
+	if _, _, err := Respond(responderKey, request, now.Add(2*MaxClockSkew)); !errors.Is(err, ErrStaleRequest) {
+		t.Fatalf("expected ErrStaleRequest, got %v", err)
+	}
+
+	if _, _, err := Respond(attackerKey, request, now); err == nil {
+		t.Fatalf("expected a request addressed to another node to be rejected")
+	}
+
+	// An attacker answering with its own key cannot authenticate the
+	// response.
+	_, forgedRequest, err := Initiate(attackerKey, initiatorKey.PublicKey(), now)
+	if err != nil {
+		t.Fatalf("failed to initiate: %v", err)
+	}
+	forgedResponse, _, err := Respond(initiatorKey, forgedRequest, now)
+	if err != nil {
+		t.Fatalf("failed to respond: %v", err)
+	}
+	if _, err := initiator.Finish(forgedResponse); !errors.Is(err, ErrInvalidMAC) {
+		t.Fatalf("expected ErrInvalidMAC for a forged response, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStreamRegistry is a Registry backed by a NATS JetStream KV bucket.
+type JetStreamRegistry struct {
+	kv jetstream.KeyValue
+}
+
+var _ Registry = (*JetStreamRegistry)(nil)
+
+func NewJetStreamRegistry(kv jetstream.KeyValue) *JetStreamRegistry {
+	return &JetStreamRegistry{
+		kv: kv,
+	}
+}
+
+func (r *JetStreamRegistry) Get(ctx context.Context, key string) (Entry, error) {
+	entry, err := r.kv.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return Entry{}, fmt.Errorf("%w: %w", ErrKeyNotFound, err)
+		}
+		return Entry{}, err
+	}
+
+	return toEntry(entry), nil
+}
+
+func (r *JetStreamRegistry) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	return r.kv.Put(ctx, key, value)
+}
+
+func (r *JetStreamRegistry) Create(ctx context.Context, key string, value []byte) (uint64, error) {
+	revision, err := r.kv.Create(ctx, key, value)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return 0, fmt.Errorf("%w: %w", ErrKeyExists, err)
+		}
+		return 0, err
+	}
+
+	return revision, nil
+}
+
+func (r *JetStreamRegistry) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	revision, err := r.kv.Update(ctx, key, value, revision)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return 0, fmt.Errorf("%w: %w", ErrWrongRevision, err)
+		}
+		return 0, err
+	}
+
+	return revision, nil
+}
+
+func (r *JetStreamRegistry) Delete(ctx context.Context, key string, revision uint64) error {
+	var opts []jetstream.KVDeleteOpt
+	if revision != 0 {
+		opts = append(opts, jetstream.LastRevision(revision))
+	}
+
+	err := r.kv.Delete(ctx, key, opts...)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return fmt.Errorf("%w: %w", ErrWrongRevision, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *JetStreamRegistry) Watch(ctx context.Context, pattern string) (Watcher, error) {
+	watcher, err := r.kv.Watch(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &jetStreamWatcher{
+		watcher: watcher,
+		updates: make(chan *Entry),
+		stop:    make(chan struct{}),
+	}
+	go w.forward()
+
+	return w, nil
+}
+
+type jetStreamWatcher struct {
+	watcher  jetstream.KeyWatcher
+	updates  chan *Entry
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (w *jetStreamWatcher) forward() {
+	defer close(w.updates)
+
+	for entry := range w.watcher.Updates() {
+		var e *Entry
+		if entry != nil {
+			converted := toEntry(entry)
+			e = &converted
+		}
+
+		select {
+		case w.updates <- e:
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *jetStreamWatcher) Updates() <-chan *Entry {
+	return w.updates
+}
+
+func (w *jetStreamWatcher) Stop() error {
+	var err error
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		err = w.watcher.Stop()
+	})
+
+	return err
+}
+
+func toEntry(entry jetstream.KeyValueEntry) Entry {
+	operation := OperationPut
+	switch entry.Operation() {
+	case jetstream.KeyValueDelete:
+		operation = OperationDelete
+	case jetstream.KeyValuePurge:
+		operation = OperationPurge
+	}
+
+	return Entry{
+		Key:       entry.Key(),
+		Value:     entry.Value(),
+		Revision:  entry.Revision(),
+		Created:   entry.Created(),
+		Operation: operation,
+	}
+}
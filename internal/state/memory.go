@@ -0,0 +1,218 @@
+package state
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRegistry is an in-memory Registry. It follows the semantics of the
+// JetStream KV bucket and is meant for tests and single node setups.
+type MemoryRegistry struct {
+	mutex    sync.Mutex
+	revision uint64
+	entries  map[string]Entry
+	watchers map[*memoryWatcher]struct{}
+}
+
+var _ Registry = (*MemoryRegistry)(nil)
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		entries:  make(map[string]Entry),
+		watchers: make(map[*memoryWatcher]struct{}),
+	}
+}
+
+func (r *MemoryRegistry) Get(ctx context.Context, key string) (Entry, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok || entry.Operation != OperationPut {
+		return Entry{}, ErrKeyNotFound
+	}
+
+	return entry, nil
+}
+
+func (r *MemoryRegistry) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.write(key, value, OperationPut), nil
+}
+
+func (r *MemoryRegistry) Create(ctx context.Context, key string, value []byte) (uint64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if entry, ok := r.entries[key]; ok && entry.Operation == OperationPut {
+		return 0, ErrKeyExists
+	}
+
+	return r.write(key, value, OperationPut), nil
+}
+
+func (r *MemoryRegistry) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.entries[key].Revision != revision {
+		return 0, ErrWrongRevision
+	}
+
+	return r.write(key, value, OperationPut), nil
+}
+
+func (r *MemoryRegistry) Delete(ctx context.Context, key string, revision uint64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if revision != 0 && r.entries[key].Revision != revision {
+		return ErrWrongRevision
+	}
+
+	r.write(key, nil, OperationDelete)
+
+	return nil
+}
+
+func (r *MemoryRegistry) Watch(ctx context.Context, pattern string) (Watcher, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	w := &memoryWatcher{
+		registry: r,
+		pattern:  strings.Split(pattern, "."),
+		updates:  make(chan *Entry),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+
+	initial := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		if w.matches(entry.Key) {
+			initial = append(initial, entry)
+		}
+	}
+	slices.SortFunc(initial, func(a, b Entry) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	for _, entry := range initial {
+		w.queue = append(w.queue, &entry)
+	}
+	w.queue = append(w.queue, nil)
+
+	r.watchers[w] = struct{}{}
+	go w.forward(ctx)
+
+	return w, nil
+}
+
+func (r *MemoryRegistry) write(key string, value []byte, operation Operation) uint64 {
+	r.revision++
+	entry := Entry{
+		Key:       key,
+		Value:     value,
+		Revision:  r.revision,
+		Created:   time.Now(),
+		Operation: operation,
+	}
+	r.entries[key] = entry
+
+	for w := range r.watchers {
+		if w.matches(key) {
+			w.push(entry)
+		}
+	}
+
+	return entry.Revision
+}
+
+type memoryWatcher struct {
+	registry *MemoryRegistry
+	pattern  []string
+	updates  chan *Entry
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// queue is protected by the registry mutex.
+	queue  []*Entry
+	notify chan struct{}
+}
+
+func (w *memoryWatcher) matches(key string) bool {
+	tokens := strings.Split(key, ".")
+	for i, token := range w.pattern {
+		if token == ">" {
+			return len(tokens) > i
+		}
+		if i >= len(tokens) {
+			return false
+		}
+		if token != "*" && token != tokens[i] {
+			return false
+		}
+	}
+
+	return len(tokens) == len(w.pattern)
+}
+
+func (w *memoryWatcher) push(entry Entry) {
+	w.queue = append(w.queue, &entry)
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *memoryWatcher) forward(ctx context.Context) {
+	defer close(w.updates)
+
+	for {
+		w.registry.mutex.Lock()
+		queue := w.queue
+		w.queue = nil
+		w.registry.mutex.Unlock()
+
+		for _, entry := range queue {
+			select {
+			case w.updates <- entry:
+			case <-w.stop:
+				return
+			case <-ctx.Done():
+				w.Stop()
+				return
+			}
+		}
+
+		select {
+		case <-w.notify:
+		case <-w.stop:
+			return
+		case <-ctx.Done():
+			w.Stop()
+			return
+		}
+	}
+}
+
+func (w *memoryWatcher) Updates() <-chan *Entry {
+	return w.updates
+}
+
+func (w *memoryWatcher) Stop() error {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+
+		w.registry.mutex.Lock()
+		delete(w.registry.watchers, w)
+		w.registry.mutex.Unlock()
+	})
+
+	return nil
+}
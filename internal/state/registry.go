@@ -0,0 +1,47 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrKeyNotFound   = errors.New("key not found")
+	ErrKeyExists     = errors.New("key exists")
+	ErrWrongRevision = errors.New("wrong revision")
+)
+
+type Operation int
+
+const (
+	OperationPut Operation = iota
+	OperationDelete
+	OperationPurge
+)
+
+type Entry struct {
+	Key       string
+	Value     []byte
+	Revision  uint64
+	Created   time.Time
+	Operation Operation
+}
+
+// Watcher delivers the initial snapshot, a nil entry, and then every update.
+type Watcher interface {
+	Updates() <-chan *Entry
+	Stop() error
+}
+
+// Registry is a revisioned key value store with NATS subject patterns.
+type Registry interface {
+	Get(ctx context.Context, key string) (Entry, error)
+	Put(ctx context.Context, key string, value []byte) (uint64, error)
+	// Create fails with ErrKeyExists if the key already holds a value.
+	Create(ctx context.Context, key string, value []byte) (uint64, error)
+	Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error)
+	// Delete ignores the revision if it is zero.
+	Delete(ctx context.Context, key string, revision uint64) error
+	Watch(ctx context.Context, pattern string) (Watcher, error)
+}
@@ -3,31 +3,127 @@ package state
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
-	"github.com/nats-io/nats.go/jetstream"
 	"github.com/valyentdev/ikto/pkg/types"
 )
 
 type SyncedState struct {
-	stop   chan struct{}
-	finish chan struct{}
-	config Config
-	peers  map[string]types.Peer
-	mutex  sync.RWMutex
+	stop     chan struct{}
+	finish   chan struct{}
+	stopOnce sync.Once
+	cancel   context.CancelFunc
+	resync   chan struct{}
+	config   Config
+	peers    map[string]peerEntry
+	status   Status
+	mutex    sync.RWMutex
+
+	heartbeats    map[string]heartbeatEntry
+	deleteWaiters map[string][]chan struct{}
+	// endpoints is keyed by the peer key of the observing node.
+	endpoints map[string]types.ObservedEndpoints
+	// relays is keyed by the peer key of the relayed node.
+	relays map[string]types.RelayPaths
+
+	ignored   map[types.PublicKey]struct{}
+	rejected  map[string]RejectedPeer
+	approvals map[string]types.Approval
+
+	policies map[string]types.Policy
+	topology types.Topology
+	labels   map[string]string
+	reselect chan struct{}
 }
 
 type Config struct {
-	KV         jetstream.KeyValue
+	Registry   Registry
 	IgnorePeer types.PublicKey
 
+	// GracePeriod is how long a peer stays alive without heartbeat.
+	GracePeriod time.Duration
+
+	// ResyncInterval, unless zero, periodically re-creates the watchers.
+	ResyncInterval time.Duration
+
+	// The records failing VerifyPeer are reported by RejectedPeers.
+	VerifyPeer func(data []byte) error
+
+	VerifyPolicy func(data []byte) error
+
+	// RequireApproval rejects the records of the peers without approval.
+	RequireApproval bool
+	VerifyApproval  func(data []byte) error
+	// Approvers are the names of the nodes whose approvals are accepted.
+	Approvers []string
+
+	// WatchEndpoints follows the endpoints observed by the peers.
+	WatchEndpoints  bool
+	VerifyEndpoints func(data []byte) error
+
+	// WatchRelays follows the peers relayed by the other nodes.
+	WatchRelays  bool
+	VerifyRelays func(data []byte) error
+
+	// Labels are matched by the topology policies.
+	Labels map[string]string
+
+	// The callbacks only see the peers selected by the topology policies.
+
 	OnPeerPut    func(peer types.Peer)
 	OnPeerDelete func(peer types.Peer)
 	OnInitPeers  func(map[string]types.Peer)
 }
 
+type peerEntry struct {
+	peer      types.Peer
+	revision  uint64
+	firstSeen time.Time
+	selected  bool
+}
+
+type heartbeatEntry struct {
+	publicKey types.PublicKey
+	seen      time.Time
+}
+
+const (
+	minResyncBackoff = time.Second
+	maxResyncBackoff = 30 * time.Second
+)
+
+// Status reports the health of the synchronization.
+type Status struct {
+	Watching  bool
+	LastSync  time.Time
+	Resyncs   uint64
+	LastError string
+}
+
+// RejectedPeer describes a peer record that failed the verification.
+type RejectedPeer struct {
+	Peer   types.Peer
+	Reason string
+	Since  time.Time
+}
+
+// PeerStatus describes a known peer along with its liveness.
+type PeerStatus struct {
+	Peer     types.Peer
+	Revision uint64
+	LastSeen time.Time
+	Alive    bool
+	// Selected is set when the topology policies select the peer.
+	Selected bool
+}
+
 func New(config Config) *SyncedState {
 	if config.OnPeerPut == nil {
 		config.OnPeerPut = func(peer types.Peer) {}
@@ -44,129 +140,1010 @@ func New(config Config) *SyncedState {
 	return &SyncedState{
 		stop:   make(chan struct{}),
 		finish: make(chan struct{}),
-		peers:  make(map[string]types.Peer),
+		resync: make(chan struct{}, 1),
+		peers:  make(map[string]peerEntry),
 		config: config,
+
+		heartbeats:    make(map[string]heartbeatEntry),
+		deleteWaiters: make(map[string][]chan struct{}),
+		endpoints:     make(map[string]types.ObservedEndpoints),
+		relays:        make(map[string]types.RelayPaths),
+
+		ignored:   map[types.PublicKey]struct{}{config.IgnorePeer: {}},
+		rejected:  make(map[string]RejectedPeer),
+		approvals: make(map[string]types.Approval),
+
+		policies: make(map[string]types.Policy),
+		labels:   maps.Clone(config.Labels),
+		reselect: make(chan struct{}, 1),
 	}
 }
 
+// Ignore ignores the records of publicKey in addition to IgnorePeer.
+func (w *SyncedState) Ignore(publicKey types.PublicKey) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.ignored[publicKey] = struct{}{}
+}
+
+func (w *SyncedState) ignores(publicKey types.PublicKey) bool {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	_, ok := w.ignored[publicKey]
+	return ok
+}
+
 func (w *SyncedState) Start(ctx context.Context) error {
-	kv := w.config.KV
-	sub := "peers.*"
-	watcher, err := kv.Watch(ctx, sub)
+	ctx, cancel := context.WithCancel(ctx)
+
+	watch, err := w.watch(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to watch: %w", err)
+		cancel()
+		return err
 	}
-
-	slog.Info("Started watching peers")
-	updates := watcher.Updates()
-	w.init(updates)
+	w.cancel = cancel
 
 	go func() {
 		slog.Info("Started continuous peer synchronization")
-		w.sync(updates)
+		w.run(ctx, watch)
 	}()
 
 	return nil
 }
 
-func (w *SyncedState) init(entries <-chan jetstream.KeyValueEntry) {
+type watch struct {
+	policies   Watcher
+	peers      Watcher
+	heartbeats Watcher
+	// approvals, endpoints and relays are nil unless enabled.
+	approvals Watcher
+	endpoints Watcher
+	relays    Watcher
+}
+
+func (w *watch) stop() {
+	w.policies.Stop()
+	w.peers.Stop()
+	w.heartbeats.Stop()
+	if w.approvals != nil {
+		w.approvals.Stop()
+	}
+	if w.endpoints != nil {
+		w.endpoints.Stop()
+	}
+	if w.relays != nil {
+		w.relays.Stop()
+	}
+}
+
+// watch creates the watchers and applies their initial snapshot.
+func (w *SyncedState) watch(ctx context.Context) (*watch, error) {
+	registry := w.config.Registry
+
+	// The policies and approvals apply to the initial peers.
+	policyWatcher, err := registry.Watch(ctx, "policies.*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch policies: %w", err)
+	}
+
+	var approvalWatcher Watcher
+	if w.config.RequireApproval {
+		approvalWatcher, err = registry.Watch(ctx, "approvals.*")
+		if err != nil {
+			policyWatcher.Stop()
+			return nil, fmt.Errorf("failed to watch approvals: %w", err)
+		}
+	}
+
+	sub := "peers.*"
+	watcher, err := registry.Watch(ctx, sub)
+	if err != nil {
+		policyWatcher.Stop()
+		if approvalWatcher != nil {
+			approvalWatcher.Stop()
+		}
+		return nil, fmt.Errorf("failed to watch: %w", err)
+	}
+
+	heartbeatWatcher, err := registry.Watch(ctx, "heartbeats.*")
+	if err != nil {
+		policyWatcher.Stop()
+		if approvalWatcher != nil {
+			approvalWatcher.Stop()
+		}
+		watcher.Stop()
+		return nil, fmt.Errorf("failed to watch heartbeats: %w", err)
+	}
+
+	var endpointWatcher Watcher
+	if w.config.WatchEndpoints {
+		endpointWatcher, err = registry.Watch(ctx, "endpoints.*")
+		if err != nil {
+			policyWatcher.Stop()
+			if approvalWatcher != nil {
+				approvalWatcher.Stop()
+			}
+			watcher.Stop()
+			heartbeatWatcher.Stop()
+			return nil, fmt.Errorf("failed to watch observed endpoints: %w", err)
+		}
+	}
+
+	var relayWatcher Watcher
+	if w.config.WatchRelays {
+		relayWatcher, err = registry.Watch(ctx, "relays.*")
+		if err != nil {
+			policyWatcher.Stop()
+			if approvalWatcher != nil {
+				approvalWatcher.Stop()
+			}
+			watcher.Stop()
+			heartbeatWatcher.Stop()
+			if endpointWatcher != nil {
+				endpointWatcher.Stop()
+			}
+			return nil, fmt.Errorf("failed to watch relays: %w", err)
+		}
+	}
+
+	slog.Info("Started watching peers")
+	w.initPolicies(policyWatcher.Updates())
+	if approvalWatcher != nil {
+		w.initApprovals(approvalWatcher.Updates())
+	}
+	w.init(watcher.Updates())
+	w.initHeartbeats(heartbeatWatcher.Updates())
+	if endpointWatcher != nil {
+		w.initEndpoints(endpointWatcher.Updates())
+	}
+	if relayWatcher != nil {
+		w.initRelays(relayWatcher.Updates())
+	}
+
+	w.mutex.Lock()
+	w.status.Watching = true
+	w.status.LastSync = time.Now()
+	w.mutex.Unlock()
+
+	return &watch{
+		policies:   policyWatcher,
+		peers:      watcher,
+		heartbeats: heartbeatWatcher,
+		approvals:  approvalWatcher,
+		endpoints:  endpointWatcher,
+		relays:     relayWatcher,
+	}, nil
+}
+
+// run synchronizes peers until the state is stopped, re-creating the
+// watchers when they fail or a resync is requested.
+func (w *SyncedState) run(ctx context.Context, current *watch) {
+	defer close(w.finish)
+
+	for {
+		err := w.sync(current)
+		current.stop()
+		if err == nil {
+			return
+		}
+
+		w.mutex.Lock()
+		w.status.Watching = false
+		if err != errResyncRequested {
+			w.status.LastError = err.Error()
+		}
+		w.mutex.Unlock()
+
+		slog.Warn("Restarting peer synchronization", "reason", err)
+
+		backoff := minResyncBackoff
+		for {
+			current, err = w.watch(ctx)
+			if err == nil {
+				break
+			}
+
+			slog.Error("failed to restart peer synchronization", "error", err, "retry_in", backoff)
+			w.mutex.Lock()
+			w.status.LastError = err.Error()
+			w.mutex.Unlock()
+
+			select {
+			case <-w.stop:
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff = min(backoff*2, maxResyncBackoff)
+		}
+
+		w.mutex.Lock()
+		w.status.Resyncs++
+		w.mutex.Unlock()
+	}
+}
+
+func (w *SyncedState) init(entries <-chan *Entry) {
+	now := time.Now()
+	present := make(map[string]struct{})
+	entriesByKey := make(map[string]peerEntry)
+	rejected := make(map[string]RejectedPeer)
 	for entry := range entries {
 		if entry == nil {
 			break
 		}
 
-		if entry.Operation() != jetstream.KeyValuePut {
+		if entry.Operation != OperationPut {
 			continue
 		}
 
-		peer, err := readPeer(entry.Value())
+		present[entry.Key] = struct{}{}
+
+		peer, err := readPeer(entry.Value)
 		if err != nil {
 			slog.Error("failed to read peer", "error", err)
 			continue
 		}
 
-		if peer.PublicKey == w.config.IgnorePeer {
+		if w.ignores(peer.PublicKey) {
+			continue
+		}
+
+		if err := w.verify(peer, entry.Value); err != nil {
+			slog.Warn("rejecting peer record", "error", err, "name", peer.Name, "public_key", peer.PublicKey.String(), "ip", peer.AllowedIP)
+			rejected[entry.Key] = w.rejection(entry.Key, peer, err)
 			continue
 		}
 
-		w.peers[entry.Key()] = peer
+		entriesByKey[entry.Key] = peerEntry{
+			peer:      peer,
+			revision:  entry.Revision,
+			firstSeen: now,
+		}
+	}
+
+	peers := make(map[string]types.Peer)
+	w.mutex.Lock()
+	for key, entry := range entriesByKey {
+		if previous, ok := w.peers[key]; ok && previous.peer.PublicKey == entry.peer.PublicKey {
+			entry.firstSeen = previous.firstSeen
+		}
+		entry.selected = w.selects(entry.peer)
+		entriesByKey[key] = entry
+
+		if entry.selected {
+			peers[key] = entry.peer
+		}
 	}
-	slog.Info("Initializing peers", "count", len(w.peers))
-	w.config.OnInitPeers(w.peers)
+	w.peers = entriesByKey
+	w.rejected = rejected
+
+	for key, waiters := range w.deleteWaiters {
+		if _, ok := present[key]; ok {
+			continue
+		}
+		for _, waiter := range waiters {
+			close(waiter)
+		}
+		delete(w.deleteWaiters, key)
+	}
+	w.mutex.Unlock()
+
+	slog.Info("Initializing peers", "count", len(peers), "known", len(entriesByKey))
+	w.config.OnInitPeers(peers)
 }
 
-func (w *SyncedState) sync(entries <-chan jetstream.KeyValueEntry) {
+func (w *SyncedState) initPolicies(entries <-chan *Entry) {
+	w.mutex.Lock()
+	w.policies = make(map[string]types.Policy)
+	w.mutex.Unlock()
+
+	for entry := range entries {
+		if entry == nil {
+			break
+		}
+
+		w.onPolicy(entry)
+	}
+}
+
+func (w *SyncedState) initHeartbeats(entries <-chan *Entry) {
+	w.mutex.Lock()
+	w.heartbeats = make(map[string]heartbeatEntry)
+	w.mutex.Unlock()
+
+	for entry := range entries {
+		if entry == nil {
+			break
+		}
+
+		w.onHeartbeat(entry)
+	}
+}
+
+func (w *SyncedState) initApprovals(entries <-chan *Entry) {
+	w.mutex.Lock()
+	w.approvals = make(map[string]types.Approval)
+	w.mutex.Unlock()
+
+	for entry := range entries {
+		if entry == nil {
+			break
+		}
+
+		w.onApproval(entry)
+	}
+}
+
+func (w *SyncedState) initEndpoints(entries <-chan *Entry) {
+	w.mutex.Lock()
+	w.endpoints = make(map[string]types.ObservedEndpoints)
+	w.mutex.Unlock()
+
+	for entry := range entries {
+		if entry == nil {
+			break
+		}
+
+		w.onEndpoints(entry)
+	}
+}
+
+func (w *SyncedState) initRelays(entries <-chan *Entry) {
+	w.mutex.Lock()
+	w.relays = make(map[string]types.RelayPaths)
+	w.mutex.Unlock()
+
+	for entry := range entries {
+		if entry == nil {
+			break
+		}
+
+		w.onRelays(entry)
+	}
+}
+
+var errResyncRequested = errors.New("resync requested")
+var errWatcherClosed = errors.New("watcher closed")
+
+// sync returns nil once stopped, or an error to re-create the watchers.
+func (w *SyncedState) sync(current *watch) error {
+	entries := current.peers.Updates()
+	heartbeats := current.heartbeats.Updates()
+	policies := current.policies.Updates()
+	var approvals <-chan *Entry
+	if current.approvals != nil {
+		approvals = current.approvals.Updates()
+	}
+	var endpoints <-chan *Entry
+	if current.endpoints != nil {
+		endpoints = current.endpoints.Updates()
+	}
+	var relays <-chan *Entry
+	if current.relays != nil {
+		relays = current.relays.Updates()
+	}
+
+	var resync <-chan time.Time
+	if w.config.ResyncInterval > 0 {
+		ticker := time.NewTicker(w.config.ResyncInterval)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
 	for {
 
 		select {
 		case <-w.stop:
-			close(w.finish)
-			return
-		case entry := <-entries:
+			return nil
+		case <-w.resync:
+			return errResyncRequested
+		case <-resync:
+			return errResyncRequested
+		case <-w.reselect:
+			w.applyTopology()
+		case entry, ok := <-policies:
+			if !ok {
+				return errWatcherClosed
+			}
+			if entry == nil {
+				continue
+			}
+
+			w.onPolicy(entry)
+			w.applyTopology()
+		case entry, ok := <-heartbeats:
+			if !ok {
+				return errWatcherClosed
+			}
+			if entry == nil {
+				continue
+			}
+
+			w.onHeartbeat(entry)
+		case entry, ok := <-approvals:
+			if !ok {
+				return errWatcherClosed
+			}
+			if entry == nil {
+				continue
+			}
+
+			if w.onApproval(entry) {
+				return errResyncRequested
+			}
+		case entry, ok := <-endpoints:
+			if !ok {
+				return errWatcherClosed
+			}
 			if entry == nil {
 				continue
 			}
 
-			key := entry.Key()
+			w.onEndpoints(entry)
+		case entry, ok := <-relays:
+			if !ok {
+				return errWatcherClosed
+			}
+			if entry == nil {
+				continue
+			}
 
-			switch entry.Operation() {
-			case jetstream.KeyValuePut:
-				peer, err := readPeer(entry.Value())
+			w.onRelays(entry)
+		case entry, ok := <-entries:
+			if !ok {
+				return errWatcherClosed
+			}
+			if entry == nil {
+				continue
+			}
+
+			key := entry.Key
+
+			switch entry.Operation {
+			case OperationPut:
+				peer, err := readPeer(entry.Value)
 				if err != nil {
 					slog.Error("failed to read peer", "error", err)
 					continue
 				}
-				w.onPeerPut(key, peer)
-			case jetstream.KeyValueDelete:
+				if err := w.verify(peer, entry.Value); err != nil {
+					w.onPeerRejected(key, peer, err)
+					continue
+				}
+				w.onPeerPut(key, peer, entry.Revision)
+			case OperationDelete:
 				w.onPeerDelete(key)
-			case jetstream.KeyValuePurge:
+			case OperationPurge:
 				w.onPeerDelete(key)
 			}
 		}
 	}
 }
 
-func (w *SyncedState) onPeerPut(key string, peer types.Peer) {
-	if peer.PublicKey == w.config.IgnorePeer {
+func (w *SyncedState) onPeerPut(key string, peer types.Peer, revision uint64) {
+	if w.ignores(peer.PublicKey) {
 		return
 	}
-	slog.Info("Peer put", "public_key", peer.PublicKey.String(), "ip", peer.AllowedIP)
 	w.mutex.Lock()
-	w.peers[key] = peer
+	firstSeen := time.Now()
+	previous, existed := w.peers[key]
+	if existed && previous.peer.PublicKey == peer.PublicKey {
+		firstSeen = previous.firstSeen
+	}
+	selected := w.selects(peer)
+	w.peers[key] = peerEntry{
+		peer:      peer,
+		revision:  revision,
+		firstSeen: firstSeen,
+		selected:  selected,
+	}
+	delete(w.rejected, key)
 	w.mutex.Unlock()
-	w.config.OnPeerPut(peer)
 
+	slog.Info("Peer put", "public_key", peer.PublicKey.String(), "ip", peer.AllowedIP, "selected", selected)
+	if selected {
+		w.config.OnPeerPut(peer)
+	} else if existed && previous.selected {
+		w.config.OnPeerDelete(previous.peer)
+	}
 }
 
 func (w *SyncedState) onPeerDelete(key string) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
-	peer, ok := w.peers[key]
+	for _, waiter := range w.deleteWaiters[key] {
+		close(waiter)
+	}
+	delete(w.deleteWaiters, key)
+	delete(w.rejected, key)
+
+	w.dropPeer(key)
+}
+
+func (w *SyncedState) onPeerRejected(key string, peer types.Peer, err error) {
+	if w.ignores(peer.PublicKey) {
+		return
+	}
+	slog.Warn("rejecting peer record", "error", err, "name", peer.Name, "public_key", peer.PublicKey.String(), "ip", peer.AllowedIP)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.rejected[key] = w.rejection(key, peer, err)
+	w.dropPeer(key)
+}
+
+// dropPeer must be called with the mutex held.
+func (w *SyncedState) dropPeer(key string) {
+	entry, ok := w.peers[key]
 	if !ok {
 		return
 	}
 
+	peer := entry.peer
 	slog.Info("Peer delete", "public_key", peer.PublicKey.String(), "ip", peer.AllowedIP)
 	delete(w.peers, key)
-	w.config.OnPeerDelete(peer)
+	if entry.selected {
+		w.config.OnPeerDelete(peer)
+	}
+}
+
+// selects must be called with the mutex held.
+func (w *SyncedState) selects(peer types.Peer) bool {
+	return w.topology.Allows(w.labels, peer.Labels)
+}
+
+func (w *SyncedState) onPolicy(entry *Entry) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	delete(w.policies, entry.Key)
+	if entry.Operation == OperationPut {
+		policy, err := readPolicy(entry.Value)
+		if err == nil {
+			err = policy.Validate()
+		}
+		if err == nil && w.config.VerifyPolicy != nil {
+			err = w.config.VerifyPolicy(entry.Value)
+		}
+		if err != nil {
+			slog.Error("ignoring invalid topology policy", "error", err, "key", entry.Key)
+		} else {
+			w.policies[entry.Key] = policy
+		}
+	}
+
+	policies := make([]types.Policy, 0, len(w.policies))
+	for _, key := range slices.Sorted(maps.Keys(w.policies)) {
+		policies = append(policies, w.policies[key])
+	}
+
+	topology, err := types.NewTopology(policies)
+	if err != nil {
+		slog.Error("failed to apply topology policies", "error", err)
+		return
+	}
+	w.topology = topology
+}
+
+// applyTopology is only called from the synchronization goroutine.
+func (w *SyncedState) applyTopology() {
+	var added, removed []types.Peer
+
+	w.mutex.Lock()
+	for key, entry := range w.peers {
+		selected := w.selects(entry.peer)
+		if selected == entry.selected {
+			continue
+		}
+
+		entry.selected = selected
+		w.peers[key] = entry
+		if selected {
+			added = append(added, entry.peer)
+		} else {
+			removed = append(removed, entry.peer)
+		}
+	}
+	w.mutex.Unlock()
+
+	if len(added) > 0 || len(removed) > 0 {
+		slog.Info("Applying topology policies", "added", len(added), "removed", len(removed))
+	}
+
+	for _, peer := range removed {
+		w.config.OnPeerDelete(peer)
+	}
+	for _, peer := range added {
+		w.config.OnPeerPut(peer)
+	}
+}
+
+// SetLabels changes the labels matched by the topology policies.
+func (w *SyncedState) SetLabels(labels map[string]string) {
+	w.mutex.Lock()
+	w.labels = maps.Clone(labels)
+	w.mutex.Unlock()
+
+	select {
+	case w.reselect <- struct{}{}:
+	default:
+	}
+}
+
+// Policies returns the topology policies currently enforced.
+func (w *SyncedState) Policies() []types.Policy {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	policies := make([]types.Policy, 0, len(w.policies))
+	for _, key := range slices.Sorted(maps.Keys(w.policies)) {
+		policies = append(policies, w.policies[key])
+	}
+
+	return policies
+}
+
+func (w *SyncedState) onHeartbeat(entry *Entry) {
+	key := "peers." + strings.TrimPrefix(entry.Key, "heartbeats.")
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if entry.Operation != OperationPut {
+		delete(w.heartbeats, key)
+		return
+	}
+
+	var heartbeat types.Heartbeat
+	if err := json.Unmarshal(entry.Value, &heartbeat); err != nil {
+		slog.Error("failed to read heartbeat", "error", err)
+		return
+	}
+
+	w.heartbeats[key] = heartbeatEntry{
+		publicKey: heartbeat.PublicKey,
+		seen:      entry.Created,
+	}
+}
+
+// onApproval reports whether the approval concerns a known peer record.
+func (w *SyncedState) onApproval(entry *Entry) bool {
+	approval, valid := w.readApproval(entry)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	previous, ok := w.approvals[entry.Key]
+	if ok == valid && (!valid || (previous.PublicKey == approval.PublicKey && previous.Name == approval.Name && previous.Approver == approval.Approver)) {
+		return false
+	}
+	if valid {
+		w.approvals[entry.Key] = approval
+	} else {
+		delete(w.approvals, entry.Key)
+	}
+
+	concerns := func(publicKey types.PublicKey) bool {
+		return (valid && publicKey == approval.PublicKey) || (ok && publicKey == previous.PublicKey)
+	}
+	for _, peer := range w.peers {
+		if concerns(peer.peer.PublicKey) {
+			return true
+		}
+	}
+	for _, rejected := range w.rejected {
+		if concerns(rejected.Peer.PublicKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readApproval keeps the approvals stored under the key they approve and
+// issued by an approver, or by the node itself for a rotated key, besides
+// the bootstrap one.
+func (w *SyncedState) readApproval(entry *Entry) (types.Approval, bool) {
+	if entry.Operation != OperationPut {
+		return types.Approval{}, false
+	}
+
+	var approval types.Approval
+	if err := json.Unmarshal(entry.Value, &approval); err != nil {
+		slog.Error("failed to read approval", "error", err, "key", entry.Key)
+		return types.Approval{}, false
+	}
+
+	if w.config.VerifyApproval != nil {
+		if err := w.config.VerifyApproval(entry.Value); err != nil {
+			slog.Warn("rejecting approval", "error", err, "key", entry.Key)
+			return types.Approval{}, false
+		}
+	}
+
+	if entry.Key == BootstrapKey {
+		return approval, approval.Approver == approval.Name
+	}
+	if entry.Key != ApprovalKey(approval.PublicKey) {
+		slog.Warn("rejecting approval of another key", "key", entry.Key, "public_key", approval.PublicKey.String())
+		return types.Approval{}, false
+	}
+
+	first := approval
+	if previous := approval.Previous; previous != nil {
+		if approval.Approver != approval.Name || previous.Name != approval.Name || previous.Previous != nil {
+			slog.Warn("rejecting approval of a rotated key", "key", entry.Key, "name", approval.Name)
+			return types.Approval{}, false
+		}
+		first = *previous
+	}
+
+	// The rotated keys of the bootstrap node are checked against the
+	// bootstrap approval.
+	if first.Approver == first.Name {
+		if approval.Previous == nil {
+			slog.Warn("rejecting approval of a node by itself", "key", entry.Key, "name", approval.Name)
+			return types.Approval{}, false
+		}
+		return approval, true
+	}
+	if !slices.Contains(w.config.Approvers, first.Approver) {
+		slog.Warn("rejecting approval by a node which is not an approver", "key", entry.Key, "approver", first.Approver)
+		return types.Approval{}, false
+	}
+
+	return approval, true
+}
+
+func (w *SyncedState) onEndpoints(entry *Entry) {
+	key := "peers." + strings.TrimPrefix(entry.Key, "endpoints.")
+	endpoints, ok := w.readEndpoints(entry)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !ok {
+		delete(w.endpoints, key)
+		return
+	}
+	w.endpoints[key] = endpoints
+}
+
+func (w *SyncedState) readEndpoints(entry *Entry) (types.ObservedEndpoints, bool) {
+	if entry.Operation != OperationPut {
+		return types.ObservedEndpoints{}, false
+	}
+
+	var endpoints types.ObservedEndpoints
+	if err := json.Unmarshal(entry.Value, &endpoints); err != nil {
+		slog.Error("failed to read observed endpoints", "error", err)
+		return types.ObservedEndpoints{}, false
+	}
+
+	if w.config.VerifyEndpoints != nil {
+		if err := w.config.VerifyEndpoints(entry.Value); err != nil {
+			slog.Warn("rejecting observed endpoints", "error", err, "name", endpoints.Name, "public_key", endpoints.PublicKey.String())
+			return types.ObservedEndpoints{}, false
+		}
+	}
+
+	return endpoints, true
+}
+
+// ObservedEndpoints returns the endpoints observed by the known peers.
+func (s *SyncedState) ObservedEndpoints() []types.ObservedEndpoints {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	observed := make([]types.ObservedEndpoints, 0, len(s.endpoints))
+	for key, endpoints := range s.endpoints {
+		entry, ok := s.peers[key]
+		if !ok || entry.peer.PublicKey != endpoints.PublicKey || entry.peer.Name != endpoints.Name {
+			continue
+		}
+		observed = append(observed, endpoints)
+	}
+
+	return observed
+}
+
+func (w *SyncedState) onRelays(entry *Entry) {
+	key := "peers." + strings.TrimPrefix(entry.Key, "relays.")
+	relays, ok := w.readRelays(entry)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !ok {
+		delete(w.relays, key)
+		return
+	}
+	w.relays[key] = relays
+}
+
+func (w *SyncedState) readRelays(entry *Entry) (types.RelayPaths, bool) {
+	if entry.Operation != OperationPut {
+		return types.RelayPaths{}, false
+	}
+
+	var relays types.RelayPaths
+	if err := json.Unmarshal(entry.Value, &relays); err != nil {
+		slog.Error("failed to read relays", "error", err)
+		return types.RelayPaths{}, false
+	}
+
+	if w.config.VerifyRelays != nil {
+		if err := w.config.VerifyRelays(entry.Value); err != nil {
+			slog.Warn("rejecting relays", "error", err, "name", relays.Name, "public_key", relays.PublicKey.String())
+			return types.RelayPaths{}, false
+		}
+	}
+
+	return relays, true
+}
+
+// RelayPaths returns the peers relayed by the known peers.
+func (s *SyncedState) RelayPaths() []types.RelayPaths {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	paths := make([]types.RelayPaths, 0, len(s.relays))
+	for key, relays := range s.relays {
+		entry, ok := s.peers[key]
+		if !ok || entry.peer.PublicKey != relays.PublicKey || entry.peer.Name != relays.Name {
+			continue
+		}
+		paths = append(paths, relays)
+	}
+
+	return paths
+}
+
+// NotifyDelete returns a channel closed once the deletion of key is
+// received. It must be called before the delete is issued.
+func (w *SyncedState) NotifyDelete(key string) <-chan struct{} {
+	waiter := make(chan struct{})
+
+	w.mutex.Lock()
+	w.deleteWaiters[key] = append(w.deleteWaiters[key], waiter)
+	w.mutex.Unlock()
+
+	return waiter
+}
+
+// Resync re-creates the watchers, typically after a reconnection.
+func (w *SyncedState) Resync() {
+	select {
+	case w.resync <- struct{}{}:
+	default:
+	}
+}
+
+func (w *SyncedState) Status() Status {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return w.status
 }
 
 func (w *SyncedState) Stop() {
-	close(w.stop)
-	<-w.finish
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		if w.cancel != nil {
+			w.cancel()
+			<-w.finish
+		}
+	})
 }
 
+// ListPeers returns the peers selected by the topology policies.
 func (s *SyncedState) ListPeers() []types.Peer {
 	s.mutex.RLock()
 	peers := make([]types.Peer, 0, len(s.peers))
-	for _, peer := range s.peers {
-		peers = append(peers, peer)
+	for _, entry := range s.peers {
+		if entry.selected {
+			peers = append(peers, entry.peer)
+		}
 	}
 	s.mutex.RUnlock()
 	return peers
 }
 
+// ListPeerStatuses returns every known peer along with its liveness.
+func (s *SyncedState) ListPeerStatuses() []PeerStatus {
+	now := time.Now()
+
+	s.mutex.RLock()
+	statuses := make([]PeerStatus, 0, len(s.peers))
+	for key, entry := range s.peers {
+		lastSeen := entry.firstSeen
+		if heartbeat, ok := s.heartbeats[key]; ok && heartbeat.publicKey == entry.peer.PublicKey && heartbeat.seen.After(lastSeen) {
+			lastSeen = heartbeat.seen
+		}
+
+		statuses = append(statuses, PeerStatus{
+			Peer:     entry.peer,
+			Revision: entry.revision,
+			LastSeen: lastSeen,
+			Alive:    now.Sub(lastSeen) < s.config.GracePeriod,
+			Selected: entry.selected,
+		})
+	}
+	s.mutex.RUnlock()
+	return statuses
+}
+
+var ErrNotApproved = errors.New("admission not approved")
+
+func (w *SyncedState) verify(peer types.Peer, data []byte) error {
+	if w.config.VerifyPeer != nil {
+		if err := w.config.VerifyPeer(data); err != nil {
+			return err
+		}
+	}
+
+	if w.config.RequireApproval {
+		w.mutex.RLock()
+		approval, ok := w.approvals[ApprovalKey(peer.PublicKey)]
+		bootstrap, bootstrapped := w.approvals[BootstrapKey]
+		w.mutex.RUnlock()
+
+		approved := ok && approval.Name == peer.Name
+		if approved && approval.Previous != nil && approval.Previous.Approver == approval.Previous.Name {
+			approved = bootstrapped && bootstrap.PublicKey == approval.Previous.PublicKey && bootstrap.Name == peer.Name
+		}
+		if bootstrapped && bootstrap.PublicKey == peer.PublicKey && bootstrap.Name == peer.Name {
+			approved = true
+		}
+		if !approved {
+			return ErrNotApproved
+		}
+	}
+
+	return nil
+}
+
+// rejection keeps the time of a previous rejection of key.
+func (w *SyncedState) rejection(key string, peer types.Peer, err error) RejectedPeer {
+	since := time.Now()
+	if previous, ok := w.rejected[key]; ok && previous.Peer.PublicKey == peer.PublicKey {
+		since = previous.Since
+	}
+
+	return RejectedPeer{
+		Peer:   peer,
+		Reason: err.Error(),
+		Since:  since,
+	}
+}
+
+// RejectedPeers returns the peer records that failed the verification.
+func (s *SyncedState) RejectedPeers() []RejectedPeer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rejected := make([]RejectedPeer, 0, len(s.rejected))
+	for _, peer := range s.rejected {
+		rejected = append(rejected, peer)
+	}
+
+	return rejected
+}
+
+func readPolicy(data []byte) (types.Policy, error) {
+	var policy types.Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return types.Policy{}, err
+	}
+
+	return policy, nil
+}
+
 func readPeer(data []byte) (types.Peer, error) {
 	var p types.Peer
 	err := json.Unmarshal(data, &p)
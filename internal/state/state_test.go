@@ -0,0 +1,283 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/natstest"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func newPeer(t *testing.T, name string, allowedIP string) types.Peer {
+	t.Helper()
+
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return types.Peer{
+		Name:             name,
+		PublicKey:        types.PublicKey(key.PublicKey()),
+		AdvertiseAddress: "192.0.2.1",
+		AllowedIP:        allowedIP,
+		WGPort:           51820,
+	}
+}
+
+// fakeDevice records the peers configured through the SyncedState callbacks
+// like the WireGuard device would.
+type fakeDevice struct {
+	mutex sync.Mutex
+	peers map[types.PublicKey]types.Peer
+	inits int
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{
+		peers: make(map[types.PublicKey]types.Peer),
+	}
+}
+
+func (d *fakeDevice) config(registry Registry, self types.Peer) Config {
+	return Config{
+		Registry:    registry,
+		IgnorePeer:  self.PublicKey,
+		GracePeriod: time.Minute,
+		OnPeerPut: func(peer types.Peer) {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			d.peers[peer.PublicKey] = peer
+		},
+		OnPeerDelete: func(peer types.Peer) {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			delete(d.peers, peer.PublicKey)
+		},
+		OnInitPeers: func(peers map[string]types.Peer) {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			d.inits++
+			d.peers = make(map[types.PublicKey]types.Peer)
+			for _, peer := range peers {
+				d.peers[peer.PublicKey] = peer
+			}
+		},
+	}
+}
+
+func (d *fakeDevice) waitFor(t *testing.T, desc string, cond func(peers map[types.PublicKey]types.Peer) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		d.mutex.Lock()
+		ok := cond(d.peers)
+		d.mutex.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %s", desc)
+}
+
+func registries(t *testing.T) map[string]func() Registry {
+	s := natstest.StartServer(t)
+	memory := NewMemoryRegistry()
+
+	return map[string]func() Registry{
+		"jetstream": func() Registry {
+			return NewJetStreamRegistry(natstest.KeyValue(t, s))
+		},
+		"memory": func() Registry {
+			return memory
+		},
+	}
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	for name, newRegistry := range registries(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewStore(newRegistry())
+			peer := newPeer(t, "a", "fd10:1::/48")
+
+			revision, err := store.CreatePeer(ctx, peer)
+			if err != nil {
+				t.Fatalf("failed to create peer: %v", err)
+			}
+
+			_, err = store.CreatePeer(ctx, newPeer(t, "b", peer.AllowedIP))
+			if !errors.Is(err, ErrKeyExists) {
+				t.Fatalf("expected ErrKeyExists, got %v", err)
+			}
+
+			got, gotRevision, err := store.GetPeer(ctx, peer.AllowedIP)
+			if err != nil {
+				t.Fatalf("failed to get peer: %v", err)
+			}
+			if got.PublicKey != peer.PublicKey || gotRevision != revision {
+				t.Fatalf("unexpected peer %v at revision %d", got, gotRevision)
+			}
+
+			peer.AdvertiseAddress = "192.0.2.2"
+			updated, err := store.UpdatePeer(ctx, peer, revision)
+			if err != nil {
+				t.Fatalf("failed to update peer: %v", err)
+			}
+
+			_, err = store.UpdatePeer(ctx, peer, revision)
+			if !errors.Is(err, ErrWrongRevision) {
+				t.Fatalf("expected ErrWrongRevision on update, got %v", err)
+			}
+
+			err = store.DeletePeer(ctx, peer.AllowedIP, revision)
+			if !errors.Is(err, ErrWrongRevision) {
+				t.Fatalf("expected ErrWrongRevision on delete, got %v", err)
+			}
+
+			err = store.DeletePeer(ctx, peer.AllowedIP, updated)
+			if err != nil {
+				t.Fatalf("failed to delete peer: %v", err)
+			}
+
+			_, _, err = store.GetPeer(ctx, peer.AllowedIP)
+			if !errors.Is(err, ErrKeyNotFound) {
+				t.Fatalf("expected ErrKeyNotFound, got %v", err)
+			}
+
+			_, err = store.CreatePeer(ctx, peer)
+			if err != nil {
+				t.Fatalf("failed to create peer after delete: %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncedStateLifecycle(t *testing.T) {
+	s := natstest.StartServer(t)
+	ctx := context.Background()
+
+	a := newPeer(t, "a", "fd10:a::/48")
+	b := newPeer(t, "b", "fd10:b::/48")
+	c := newPeer(t, "c", "fd10:c::/48")
+
+	storeA := NewStore(NewJetStreamRegistry(natstest.KeyValue(t, s)))
+	_, err := storeA.CreatePeer(ctx, a)
+	if err != nil {
+		t.Fatalf("failed to create a: %v", err)
+	}
+
+	deviceA := newFakeDevice()
+	stateA := New(deviceA.config(NewJetStreamRegistry(natstest.KeyValue(t, s)), a))
+	if err := stateA.Start(ctx); err != nil {
+		t.Fatalf("failed to start a: %v", err)
+	}
+	defer stateA.Stop()
+
+	storeB := NewStore(NewJetStreamRegistry(natstest.KeyValue(t, s)))
+	revisionB, err := storeB.CreatePeer(ctx, b)
+	if err != nil {
+		t.Fatalf("failed to create b: %v", err)
+	}
+
+	deviceB := newFakeDevice()
+	stateB := New(deviceB.config(NewJetStreamRegistry(natstest.KeyValue(t, s)), b))
+	if err := stateB.Start(ctx); err != nil {
+		t.Fatalf("failed to start b: %v", err)
+	}
+	defer stateB.Stop()
+
+	deviceB.waitFor(t, "b to init with a", func(peers map[types.PublicKey]types.Peer) bool {
+		_, ok := peers[a.PublicKey]
+		return ok && len(peers) == 1
+	})
+	deviceA.waitFor(t, "a to see b", func(peers map[types.PublicKey]types.Peer) bool {
+		_, ok := peers[b.PublicKey]
+		return ok && len(peers) == 1
+	})
+
+	b.AdvertiseAddress = "192.0.2.42"
+	revisionB, err = storeB.UpdatePeer(ctx, b, revisionB)
+	if err != nil {
+		t.Fatalf("failed to update b: %v", err)
+	}
+	deviceA.waitFor(t, "a to see b update", func(peers map[types.PublicKey]types.Peer) bool {
+		return peers[b.PublicKey].AdvertiseAddress == "192.0.2.42"
+	})
+
+	_, err = storeA.CreatePeer(ctx, c)
+	if err != nil {
+		t.Fatalf("failed to create c: %v", err)
+	}
+	deviceB.waitFor(t, "b to see c", func(peers map[types.PublicKey]types.Peer) bool {
+		_, ok := peers[c.PublicKey]
+		return ok
+	})
+
+	deleted := stateA.NotifyDelete(PeerKey(b.AllowedIP))
+	err = storeB.DeletePeer(ctx, b.AllowedIP, revisionB)
+	if err != nil {
+		t.Fatalf("failed to delete b: %v", err)
+	}
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delete notification")
+	}
+	deviceA.waitFor(t, "a to remove b", func(peers map[types.PublicKey]types.Peer) bool {
+		_, ok := peers[b.PublicKey]
+		return !ok
+	})
+
+	err = natstest.KeyValue(t, s).Purge(ctx, PeerKey(c.AllowedIP))
+	if err != nil {
+		t.Fatalf("failed to purge c: %v", err)
+	}
+	deviceA.waitFor(t, "a to remove purged c", func(peers map[types.PublicKey]types.Peer) bool {
+		return len(peers) == 0
+	})
+	deviceB.waitFor(t, "b to remove purged c", func(peers map[types.PublicKey]types.Peer) bool {
+		_, ok := peers[c.PublicKey]
+		return !ok
+	})
+}
+
+func TestSyncedStateResync(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	store := NewStore(registry)
+
+	self := newPeer(t, "self", "fd10:1::/48")
+	other := newPeer(t, "other", "fd10:2::/48")
+
+	device := newFakeDevice()
+	state := New(device.config(registry, self))
+	if err := state.Start(ctx); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer state.Stop()
+
+	_, err := store.CreatePeer(ctx, other)
+	if err != nil {
+		t.Fatalf("failed to create peer: %v", err)
+	}
+	device.waitFor(t, "other peer", func(peers map[types.PublicKey]types.Peer) bool {
+		return len(peers) == 1
+	})
+
+	state.Resync()
+	device.waitFor(t, "resync", func(peers map[types.PublicKey]types.Peer) bool {
+		return device.inits == 2 && len(peers) == 1
+	})
+
+	if status := state.Status(); status.Resyncs != 1 || !status.Watching {
+		t.Fatalf("unexpected status after resync: %+v", status)
+	}
+}
@@ -4,28 +4,71 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
-	"github.com/nats-io/nats.go/jetstream"
 	"github.com/valyentdev/ikto/pkg/types"
 )
 
 type Store struct {
-	kv jetstream.KeyValue
+	registry      Registry
+	sign          func(peer types.Peer) ([]byte, error)
+	verify        func(data []byte) error
+	verifyPolicy  func(data []byte) error
+	signEndpoints func(endpoints types.ObservedEndpoints) ([]byte, error)
+	signRelays    func(relays types.RelayPaths) ([]byte, error)
+	signApproval  func(approval types.Approval) ([]byte, error)
 }
 
-func NewStore(kv jetstream.KeyValue) *Store {
+func NewStore(registry Registry) *Store {
 	return &Store{
-		kv: kv,
+		registry: registry,
 	}
 }
 
+// SetSigner makes the store sign the peer records it writes.
+func (s *Store) SetSigner(sign func(peer types.Peer) ([]byte, error)) {
+	s.sign = sign
+}
+
+// SetVerifier makes the store refuse to approve unverified requests.
+func (s *Store) SetVerifier(verify func(data []byte) error) {
+	s.verify = verify
+}
+
+func (s *Store) SetEndpointsSigner(sign func(endpoints types.ObservedEndpoints) ([]byte, error)) {
+	s.signEndpoints = sign
+}
+
+func (s *Store) SetRelaysSigner(sign func(relays types.RelayPaths) ([]byte, error)) {
+	s.signRelays = sign
+}
+
+func (s *Store) SetApprovalSigner(sign func(approval types.Approval) ([]byte, error)) {
+	s.signApproval = sign
+}
+
+func (s *Store) SetPolicyVerifier(verify func(data []byte) error) {
+	s.verifyPolicy = verify
+}
+
+func (s *Store) encodePeer(peer types.Peer) ([]byte, error) {
+	if s.sign != nil {
+		return s.sign(peer)
+	}
+
+	return json.Marshal(peer)
+}
+
 func (s *Store) CreatePeer(ctx context.Context, peer types.Peer) (uint64, error) {
-	bytes, err := json.Marshal(peer)
+	bytes, err := s.encodePeer(peer)
 	if err != nil {
 		return 0, err
 	}
 
-	revision, err := s.kv.Create(ctx, getKey(peer.AllowedIP), bytes)
+	revision, err := s.registry.Create(ctx, PeerKey(peer.AllowedIP), bytes)
 	if err != nil {
 		return 0, err
 	}
@@ -33,31 +76,62 @@ func (s *Store) CreatePeer(ctx context.Context, peer types.Peer) (uint64, error)
 	return revision, nil
 }
 
-func getKey(ip string) string {
+func PeerKey(ip string) string {
 	return "peers." + base64.URLEncoding.EncodeToString([]byte(ip))
 }
 
+func AddressKey(ip string) string {
+	return "addresses." + base64.URLEncoding.EncodeToString([]byte(ip))
+}
+
+func PendingKey(ip string) string {
+	return "pending." + base64.URLEncoding.EncodeToString([]byte(ip))
+}
+
+func ApprovalKey(publicKey types.PublicKey) string {
+	return "approvals." + base64.URLEncoding.EncodeToString(publicKey[:])
+}
+
+// BootstrapKey holds the approval of the first node of the mesh, by itself.
+const BootstrapKey = "approvals.bootstrap"
+
+func PolicyKey(name string) string {
+	return "policies." + base64.URLEncoding.EncodeToString([]byte(name))
+}
+
+func HeartbeatKey(ip string) string {
+	return "heartbeats." + base64.URLEncoding.EncodeToString([]byte(ip))
+}
+
+func EndpointsKey(ip string) string {
+	return "endpoints." + base64.URLEncoding.EncodeToString([]byte(ip))
+}
+
+func RelaysKey(ip string) string {
+	return "relays." + base64.URLEncoding.EncodeToString([]byte(ip))
+}
+
 func (s *Store) GetPeer(ctx context.Context, ip string) (types.Peer, uint64, error) {
-	entry, err := s.kv.Get(ctx, getKey(ip))
+	entry, err := s.registry.Get(ctx, PeerKey(ip))
 	if err != nil {
 		return types.Peer{}, 0, err
 	}
 
 	var peer types.Peer
-	if err := json.Unmarshal(entry.Value(), &peer); err != nil {
+	if err := json.Unmarshal(entry.Value, &peer); err != nil {
 		return types.Peer{}, 0, err
 	}
 
-	return peer, entry.Revision(), nil
+	return peer, entry.Revision, nil
 }
 
 func (s *Store) UpdatePeer(ctx context.Context, peer types.Peer, revision uint64) (uint64, error) {
-	bytes, err := json.Marshal(peer)
+	bytes, err := s.encodePeer(peer)
 	if err != nil {
 		return 0, err
 	}
 
-	r, err := s.kv.Update(ctx, getKey(peer.AllowedIP), bytes, revision)
+	r, err := s.registry.Update(ctx, PeerKey(peer.AllowedIP), bytes, revision)
 	if err != nil {
 		return 0, err
 	}
@@ -66,5 +140,464 @@ func (s *Store) UpdatePeer(ctx context.Context, peer types.Peer, revision uint64
 }
 
 func (s *Store) DeletePeer(ctx context.Context, ip string, revision uint64) error {
-	return s.kv.Delete(ctx, getKey(ip), jetstream.LastRevision(revision))
+	return s.registry.Delete(ctx, PeerKey(ip), revision)
+}
+
+func (s *Store) PutHeartbeat(ctx context.Context, ip string, heartbeat types.Heartbeat) (uint64, error) {
+	bytes, err := json.Marshal(heartbeat)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.registry.Put(ctx, HeartbeatKey(ip), bytes)
+}
+
+func (s *Store) DeleteHeartbeat(ctx context.Context, ip string) error {
+	return s.registry.Delete(ctx, HeartbeatKey(ip), 0)
+}
+
+func (s *Store) PutObservedEndpoints(ctx context.Context, ip string, endpoints types.ObservedEndpoints) error {
+	var bytes []byte
+	var err error
+	if s.signEndpoints != nil {
+		bytes, err = s.signEndpoints(endpoints)
+	} else {
+		bytes, err = json.Marshal(endpoints)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.registry.Put(ctx, EndpointsKey(ip), bytes)
+	return err
+}
+
+func (s *Store) DeleteObservedEndpoints(ctx context.Context, ip string) error {
+	return s.registry.Delete(ctx, EndpointsKey(ip), 0)
+}
+
+func (s *Store) PutRelayPaths(ctx context.Context, ip string, relays types.RelayPaths) error {
+	var bytes []byte
+	var err error
+	if s.signRelays != nil {
+		bytes, err = s.signRelays(relays)
+	} else {
+		bytes, err = json.Marshal(relays)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.registry.Put(ctx, RelaysKey(ip), bytes)
+	return err
+}
+
+func (s *Store) DeleteRelayPaths(ctx context.Context, ip string) error {
+	return s.registry.Delete(ctx, RelaysKey(ip), 0)
+}
+
+func (s *Store) ListPeers(ctx context.Context) ([]types.Peer, error) {
+	entries, err := s.list(ctx, "peers.*")
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]types.Peer, 0, len(entries))
+	for _, entry := range entries {
+		peer, err := readPeer(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+func (s *Store) list(ctx context.Context, pattern string) ([]Entry, error) {
+	watcher, err := s.registry.Watch(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	var entries []Entry
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				return nil, fmt.Errorf("watcher closed before the end of the snapshot")
+			}
+			if entry == nil {
+				return entries, nil
+			}
+			if entry.Operation != OperationPut {
+				continue
+			}
+
+			entries = append(entries, *entry)
+		}
+	}
+}
+
+// PendingPeer is an admission request.
+type PendingPeer struct {
+	Peer      types.Peer
+	Requested time.Time
+	// Rejection is empty if the request can be approved.
+	Rejection string
+}
+
+// RequestAdmission fails with ErrKeyExists if another peer requested the
+// address.
+func (s *Store) RequestAdmission(ctx context.Context, peer types.Peer) error {
+	bytes, err := s.encodePeer(peer)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.registry.Get(ctx, PendingKey(peer.AllowedIP))
+	if errors.Is(err, ErrKeyNotFound) {
+		_, err = s.registry.Create(ctx, PendingKey(peer.AllowedIP), bytes)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	pending, err := readPeer(entry.Value)
+	if err != nil {
+		return err
+	}
+	if pending.PublicKey != peer.PublicKey {
+		return ErrKeyExists
+	}
+
+	_, err = s.registry.Update(ctx, PendingKey(peer.AllowedIP), bytes, entry.Revision)
+	return err
+}
+
+func (s *Store) ListPending(ctx context.Context) ([]PendingPeer, error) {
+	entries, err := s.list(ctx, "pending.*")
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]PendingPeer, 0, len(entries))
+	for _, entry := range entries {
+		peer, err := readPeer(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		var rejection string
+		if err := s.verifyPeer(entry.Value); err != nil {
+			rejection = err.Error()
+		}
+
+		pending = append(pending, PendingPeer{
+			Peer:      peer,
+			Requested: entry.Created,
+			Rejection: rejection,
+		})
+	}
+
+	return pending, nil
+}
+
+// ApprovePeer promotes an admission request, as encoded by the peer, to a
+// peer record.
+func (s *Store) ApprovePeer(ctx context.Context, ip string, publicKey types.PublicKey) (types.Peer, error) {
+	entry, err := s.registry.Get(ctx, PendingKey(ip))
+	if err != nil {
+		return types.Peer{}, err
+	}
+
+	peer, err := readPeer(entry.Value)
+	if err != nil {
+		return types.Peer{}, err
+	}
+	if peer.PublicKey != publicKey {
+		return types.Peer{}, ErrKeyNotFound
+	}
+
+	if err := s.verifyPeer(entry.Value); err != nil {
+		return types.Peer{}, err
+	}
+
+	_, err = s.registry.Create(ctx, PeerKey(ip), entry.Value)
+	if errors.Is(err, ErrKeyExists) {
+		// The request may have been approved concurrently.
+		existing, _, getErr := s.GetPeer(ctx, ip)
+		if getErr != nil {
+			return types.Peer{}, getErr
+		}
+		if existing.PublicKey != publicKey {
+			return types.Peer{}, ErrKeyExists
+		}
+	} else if err != nil {
+		return types.Peer{}, err
+	}
+
+	err = s.registry.Delete(ctx, PendingKey(ip), 0)
+	if err != nil {
+		return types.Peer{}, err
+	}
+
+	return peer, nil
+}
+
+func (s *Store) encodeApproval(approval types.Approval) ([]byte, error) {
+	if s.signApproval != nil {
+		return s.signApproval(approval)
+	}
+
+	return json.Marshal(approval)
+}
+
+func (s *Store) PutApproval(ctx context.Context, approval types.Approval) error {
+	bytes, err := s.encodeApproval(approval)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.registry.Put(ctx, ApprovalKey(approval.PublicKey), bytes)
+	return err
+}
+
+// Bootstrap creates the bootstrap approval, failing with ErrKeyExists if the
+// mesh was already bootstrapped.
+func (s *Store) Bootstrap(ctx context.Context, approval types.Approval) error {
+	bytes, err := s.encodeApproval(approval)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.registry.Create(ctx, BootstrapKey, bytes)
+	return err
+}
+
+// Approval returns the approval of publicKey, or the bootstrap one if it
+// approves publicKey, failing with ErrKeyNotFound otherwise.
+func (s *Store) Approval(ctx context.Context, publicKey types.PublicKey) (types.Approval, error) {
+	entry, err := s.registry.Get(ctx, ApprovalKey(publicKey))
+	if errors.Is(err, ErrKeyNotFound) {
+		entry, err = s.registry.Get(ctx, BootstrapKey)
+	}
+	if err != nil {
+		return types.Approval{}, err
+	}
+
+	var approval types.Approval
+	if err := json.Unmarshal(entry.Value, &approval); err != nil {
+		return types.Approval{}, err
+	}
+	if approval.PublicKey != publicKey {
+		return types.Approval{}, ErrKeyNotFound
+	}
+
+	return approval, nil
+}
+
+func (s *Store) DeleteApproval(ctx context.Context, publicKey types.PublicKey) error {
+	return s.registry.Delete(ctx, ApprovalKey(publicKey), 0)
+}
+
+// CancelAdmission deletes the admission request of publicKey for ip, if any.
+func (s *Store) CancelAdmission(ctx context.Context, ip string, publicKey types.PublicKey) error {
+	entry, err := s.registry.Get(ctx, PendingKey(ip))
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	peer, err := readPeer(entry.Value)
+	if err != nil {
+		return err
+	}
+	if peer.PublicKey != publicKey {
+		return nil
+	}
+
+	return s.registry.Delete(ctx, PendingKey(ip), entry.Revision)
+}
+
+func (s *Store) verifyPeer(data []byte) error {
+	if s.verify == nil {
+		return nil
+	}
+
+	return s.verify(data)
+}
+
+func (s *Store) PutPolicy(ctx context.Context, policy types.Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if s.verifyPolicy != nil {
+		if err := s.verifyPolicy(bytes); err != nil {
+			return fmt.Errorf("policy %s: %w", policy.Name, err)
+		}
+	}
+
+	_, err = s.registry.Put(ctx, PolicyKey(policy.Name), bytes)
+	return err
+}
+
+func (s *Store) DeletePolicy(ctx context.Context, name string) error {
+	if _, err := s.registry.Get(ctx, PolicyKey(name)); err != nil {
+		return err
+	}
+
+	return s.registry.Delete(ctx, PolicyKey(name), 0)
+}
+
+func (s *Store) ListPolicies(ctx context.Context) ([]types.Policy, error) {
+	entries, err := s.list(ctx, "policies.*")
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]types.Policy, 0, len(entries))
+	for _, entry := range entries {
+		policy, err := readPolicy(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+type addressClaim struct {
+	PublicKey types.PublicKey `json:"public_key"`
+}
+
+// ClaimAddress returns whether the reservation of ip was created by this
+// call.
+func (s *Store) ClaimAddress(ctx context.Context, ip string, publicKey types.PublicKey) (bool, error) {
+	bytes, err := json.Marshal(addressClaim{PublicKey: publicKey})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.registry.Create(ctx, AddressKey(ip), bytes)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, ErrKeyExists) {
+		return false, err
+	}
+
+	claim, _, err := s.getAddressClaim(ctx, ip)
+	if err != nil {
+		return false, err
+	}
+	if claim.PublicKey != publicKey {
+		return false, ErrKeyExists
+	}
+
+	return false, nil
+}
+
+func (s *Store) ReleaseAddress(ctx context.Context, ip string, publicKey types.PublicKey) error {
+	claim, revision, err := s.getAddressClaim(ctx, ip)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if claim.PublicKey != publicKey {
+		return nil
+	}
+
+	return s.registry.Delete(ctx, AddressKey(ip), revision)
+}
+
+// TransferAddress hands the reservation of ip over after a key rotation.
+func (s *Store) TransferAddress(ctx context.Context, ip string, from types.PublicKey, to types.PublicKey) error {
+	claim, revision, err := s.getAddressClaim(ctx, ip)
+	if err != nil {
+		return err
+	}
+	if claim.PublicKey == to {
+		return nil
+	}
+	if claim.PublicKey != from {
+		return ErrKeyExists
+	}
+
+	bytes, err := json.Marshal(addressClaim{PublicKey: to})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.registry.Update(ctx, AddressKey(ip), bytes, revision)
+	return err
+}
+
+type AddressClaim struct {
+	IP        string
+	PublicKey types.PublicKey
+	Revision  uint64
+	Claimed   time.Time
+}
+
+func (s *Store) ListAddressClaims(ctx context.Context) ([]AddressClaim, error) {
+	entries, err := s.list(ctx, "addresses.*")
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make([]AddressClaim, 0, len(entries))
+	for _, entry := range entries {
+		ip, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(entry.Key, "addresses."))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address key %s: %w", entry.Key, err)
+		}
+
+		var claim addressClaim
+		if err := json.Unmarshal(entry.Value, &claim); err != nil {
+			return nil, err
+		}
+
+		claims = append(claims, AddressClaim{
+			IP:        string(ip),
+			PublicKey: claim.PublicKey,
+			Revision:  entry.Revision,
+			Claimed:   entry.Created,
+		})
+	}
+
+	return claims, nil
+}
+
+func (s *Store) DeleteAddressClaim(ctx context.Context, ip string, revision uint64) error {
+	return s.registry.Delete(ctx, AddressKey(ip), revision)
+}
+
+func (s *Store) getAddressClaim(ctx context.Context, ip string) (addressClaim, uint64, error) {
+	entry, err := s.registry.Get(ctx, AddressKey(ip))
+	if err != nil {
+		return addressClaim{}, 0, err
+	}
+
+	var claim addressClaim
+	if err := json.Unmarshal(entry.Value, &claim); err != nil {
+		return addressClaim{}, 0, err
+	}
+
+	return claim, entry.Revision, nil
 }
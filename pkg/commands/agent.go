@@ -2,7 +2,6 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
@@ -15,6 +14,7 @@ import (
 func NewAgentCommand() *cobra.Command {
 	var configPath string
 	var socket string
+	var bootstrap bool
 	cmd := &cobra.Command{
 		Use:   "agent",
 		Short: "Start the ikto agent",
@@ -23,20 +23,16 @@ func NewAgentCommand() *cobra.Command {
 				return fmt.Errorf("config path is required")
 			}
 
-			configFile, err := os.ReadFile(configPath)
+			config, err := readConfig(configPath)
 			if err != nil {
 				return err
 			}
 
-			var config Config
-			if err := json.Unmarshal(configFile, &config); err != nil {
-				return err
-			}
-
 			iktoConfig, err := config.Validate()
 			if err != nil {
 				return err
 			}
+			iktoConfig.Bootstrap = bootstrap
 
 			ikto, err := ikto.NewIkto(&iktoConfig)
 			if err != nil {
@@ -48,9 +44,18 @@ func NewAgentCommand() *cobra.Command {
 				return err
 			}
 
-			ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt)
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			go func() {
+				select {
+				case <-ikto.Left():
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
 
-			err = server.StartAdminServer(ctx, *ikto, socket)
+			err = server.StartAdminServer(ctx, ikto, socket)
 			if err != nil {
 				return err
 			}
@@ -65,6 +70,7 @@ func NewAgentCommand() *cobra.Command {
 	cmd.MarkFlagRequired("config")
 
 	cmd.Flags().StringVarP(&socket, "socket", "s", "/tmp/ikto.sock", "Path to the socket file")
+	cmd.Flags().BoolVar(&bootstrap, "bootstrap", false, "Approve the local node as the first node of a mesh requiring approval")
 
 	return cmd
 }
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/internal/authority"
+	"github.com/valyentdev/ikto/pkg/ikto"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func NewAuthorityCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "authority",
+		Short: "Manage the mesh authority signing the peer records",
+	}
+
+	cmd.AddCommand(newAuthorityGenerateCommand())
+	cmd.AddCommand(newAuthoritySignCommand())
+
+	return cmd
+}
+
+func newAuthorityGenerateCommand() *cobra.Command {
+	var keyPath string
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the key of the mesh authority",
+		Long: `Generate the key of the mesh authority and print its public key, to be set
+as authority_public_key in the configuration of every node.
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(keyPath); err == nil {
+				return fmt.Errorf("%s already exists", keyPath)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			authorityKey, err := ikto.LoadOrGenerateSigningKey(keyPath)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(authority.EncodePublicKey(authorityKey.Public().(ed25519.PublicKey)))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyPath, "key", "k", "", "Path to the authority key")
+	cmd.MarkFlagRequired("key")
+
+	return cmd
+}
+
+func newAuthoritySignCommand() *cobra.Command {
+	var keyPath string
+	var name string
+	var nodeKey string
+	var wgPublicKey string
+	var prefixes []string
+	var validity time.Duration
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Issue the certificate of a node",
+		Long: `Issue the certificate allowing the node with the given name to join the mesh
+with the node key printed by "ikto key show-public --node", the wireguard key
+printed by "ikto key show-public" and addresses within the given prefixes. The
+certificate is printed, to be saved at the certificate_path of the node.
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authorityKey, err := ikto.LoadSigningKey(keyPath)
+			if err != nil {
+				return err
+			}
+
+			publicKey, err := authority.ParsePublicKey(nodeKey)
+			if err != nil {
+				return err
+			}
+
+			wgKey, err := wgtypes.ParseKey(wgPublicKey)
+			if err != nil {
+				return fmt.Errorf("invalid wireguard public key: %w", err)
+			}
+
+			ipnets := make([]net.IPNet, 0, len(prefixes))
+			for _, prefix := range prefixes {
+				_, ipnet, err := net.ParseCIDR(prefix)
+				if err != nil {
+					return fmt.Errorf("invalid prefix %q: %w", prefix, err)
+				}
+				ipnets = append(ipnets, *ipnet)
+			}
+
+			if validity <= 0 {
+				return fmt.Errorf("validity must be positive")
+			}
+
+			certificate := authority.Sign(authorityKey, name, publicKey, types.PublicKey(wgKey), ipnets, time.Now().Add(validity))
+
+			bytes, err := json.MarshalIndent(certificate, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(append(bytes, '\n'))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyPath, "key", "k", "", "Path to the authority key")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the node")
+	cmd.Flags().StringVar(&nodeKey, "node-key", "", "Public node key of the node")
+	cmd.Flags().StringVar(&wgPublicKey, "public-key", "", "Wireguard public key of the node")
+	cmd.Flags().StringSliceVar(&prefixes, "prefix", nil, "Prefix of the addresses of the node, its allowed IP or the range it allocates from")
+	cmd.Flags().DurationVar(&validity, "validity", 365*24*time.Hour, "Validity of the certificate")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("node-key")
+	cmd.MarkFlagRequired("public-key")
+	cmd.MarkFlagRequired("prefix")
+
+	return cmd
+}
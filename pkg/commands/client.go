@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"context"
+	"net"
+
+	"github.com/valyentdev/ikto/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func newAdminClient(socket string) (proto.AdminServiceClient, error) {
+	conn, err := grpc.NewClient("0.0.0.0", grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return net.Dial("unix", socket)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.NewAdminServiceClient(conn), nil
+}
@@ -1,27 +1,132 @@
 package commands
 
 import (
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
+	"os"
+	"path"
+	"time"
 
+	"github.com/valyentdev/ikto/internal/authority"
+	"github.com/valyentdev/ikto/internal/network"
 	"github.com/valyentdev/ikto/pkg/ikto"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
 
 type Config struct {
 	Name string `json:"name"`
 
 	AdvertiseAddress string `json:"advertise_address"`
-	PrivateAddress   string `json:"private_address"`
-	HostPrefixLength int    `json:"subnet_prefix"`
-	MeshIPNet        string `json:"mesh_cidr"`
+
+	Endpoints         []string `json:"endpoints,omitempty"`
+	EndpointTimeout   string   `json:"endpoint_timeout,omitempty"`
+	ObservedEndpoints bool     `json:"observed_endpoints,omitempty"`
+	PrivateAddress    string   `json:"private_address"`
+	HostPrefixLength  int      `json:"subnet_prefix"`
+	MeshIPNet         string   `json:"mesh_cidr"`
+	AddressPath       string   `json:"address_path,omitempty"`
+
+	SecondaryMeshIPNet        string `json:"secondary_mesh_cidr,omitempty"`
+	SecondaryPrivateAddress   string `json:"secondary_private_address,omitempty"`
+	SecondaryHostPrefixLength int    `json:"secondary_subnet_prefix,omitempty"`
+
+	Routes []string `json:"routes,omitempty"`
+
+	RouteTable  int `json:"route_table,omitempty"`
+	RouteMetric int `json:"route_metric,omitempty"`
+
+	ExitNode    bool   `json:"exit_node,omitempty"`
+	UseExitNode string `json:"use_exit_node,omitempty"`
+
+	Relay        bool   `json:"relay,omitempty"`
+	RelayTimeout string `json:"relay_timeout,omitempty"`
+
+	TuningDefaults types.Tuning `json:"tuning_defaults,omitzero"`
+	Tuning         types.Tuning `json:"tuning,omitzero"`
 
 	WGDevName      string `json:"wg_dev_name"`
 	WGPort         int    `json:"wg_port"`
 	PrivateKeyPath string `json:"private_key_path"`
+	WGNetns        string `json:"wg_netns,omitempty"`
 
 	NatsCreds string `json:"nats_creds"`
 	NatsURL   string `json:"nats_url"`
 	NatsKV    string `json:"nats_kv"`
+
+	HeartbeatInterval string `json:"heartbeat_interval,omitempty"`
+	PeerGracePeriod   string `json:"peer_grace_period,omitempty"`
+	ResyncInterval    string `json:"resync_interval,omitempty"`
+
+	ReconcileInterval string `json:"reconcile_interval,omitempty"`
+	ReconcileDryRun   bool   `json:"reconcile_dry_run,omitempty"`
+
+	PresharedKeys        bool   `json:"preshared_keys,omitempty"`
+	PresharedKeyRotation string `json:"preshared_key_rotation,omitempty"`
+
+	KeyRotationInterval string `json:"key_rotation_interval,omitempty"`
+
+	AuthorityPublicKey string `json:"authority_public_key,omitempty"`
+	NodeKeyPath        string `json:"node_key_path,omitempty"`
+	CertificatePath    string `json:"certificate_path,omitempty"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	RequireApproval  bool              `json:"require_approval,omitempty"`
+	Approvers        []string          `json:"approvers,omitempty"`
+	AdmissionTimeout string            `json:"admission_timeout,omitempty"`
+	AutoApprove      AutoApproveConfig `json:"auto_approve,omitzero"`
+}
+
+type AutoApproveConfig struct {
+	PublicKeys []string `json:"public_keys,omitempty"`
+	Names      []string `json:"names,omitempty"`
+	Selector   string   `json:"selector,omitempty"`
+}
+
+const (
+	defaultPrivateKeyPath = "/var/lib/ikto/private.key"
+	defaultNodeKeyPath    = "/var/lib/ikto/node.key"
+)
+
+const (
+	defaultHeartbeatInterval    = 10 * time.Second
+	defaultPeerGracePeriod      = time.Minute
+	defaultResyncInterval       = 5 * time.Minute
+	defaultReconcileInterval    = 30 * time.Second
+	defaultPresharedKeyRotation = time.Hour
+	defaultRelayTimeout         = 3 * time.Minute
+	defaultEndpointTimeout      = 15 * time.Second
+	defaultAdmissionTimeout     = 15 * time.Minute
+
+	// WireGuard renews the handshakes every 2 minutes while traffic flows.
+	minRelayTimeout = 150 * time.Second
+)
+
+func readConfig(path string) (*Config, error) {
+	configFile, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(configFile, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return time.ParseDuration(value)
 }
 
 func (c *Config) Validate() (ikto.Config, error) {
@@ -30,24 +135,214 @@ func (c *Config) Validate() (ikto.Config, error) {
 		return ikto.Config{}, err
 	}
 
-	advertiseAddress := net.ParseIP(c.AdvertiseAddress)
-	if advertiseAddress == nil {
-		return ikto.Config{}, fmt.Errorf("advertise address is required")
+	// Without advertise address nor endpoints, the node is behind a NAT.
+	var advertiseAddress net.IP
+	if c.AdvertiseAddress != "" {
+		advertiseAddress = net.ParseIP(c.AdvertiseAddress)
+		if advertiseAddress == nil {
+			return ikto.Config{}, fmt.Errorf("advertise address is invalid")
+		}
+	}
+
+	for _, endpoint := range c.Endpoints {
+		if _, err := netip.ParseAddrPort(endpoint); err != nil {
+			return ikto.Config{}, fmt.Errorf("endpoint %q is invalid: %w", endpoint, err)
+		}
 	}
 
-	privateAddress := net.ParseIP(c.PrivateAddress)
-	if privateAddress == nil {
-		return ikto.Config{}, fmt.Errorf("private address is invalid")
+	if c.PrivateKeyPath == "" {
+		return ikto.Config{}, fmt.Errorf("private key path is required")
 	}
-	if len(ipnet.IP) == 4 {
-		privateAddress = privateAddress.To4()
-	} else {
-		privateAddress = privateAddress.To16()
+
+	ones, bits := ipnet.Mask.Size()
+	if c.HostPrefixLength < ones || c.HostPrefixLength > bits {
+		return ikto.Config{}, fmt.Errorf("subnet prefix must be between %d and %d", ones, bits)
 	}
 
-	fmt.Println(privateAddress)
-	if !ipnet.Contains(privateAddress) {
-		return ikto.Config{}, fmt.Errorf("private address is not in mesh network")
+	var privateAddress net.IP
+	if c.PrivateAddress != "" {
+		privateAddress = net.ParseIP(c.PrivateAddress)
+		if privateAddress == nil {
+			return ikto.Config{}, fmt.Errorf("private address is invalid")
+		}
+		if len(ipnet.IP) == 4 {
+			privateAddress = privateAddress.To4()
+		} else {
+			privateAddress = privateAddress.To16()
+		}
+
+		if !ipnet.Contains(privateAddress) {
+			return ikto.Config{}, fmt.Errorf("private address is not in mesh network")
+		}
+	} else if c.AddressPath == "" {
+		return ikto.Config{}, fmt.Errorf("address path is required when private address is empty")
+	}
+
+	var secondaryIPNet *net.IPNet
+	var secondaryPrivateAddress net.IP
+	if c.SecondaryMeshIPNet != "" {
+		_, secondaryIPNet, err = net.ParseCIDR(c.SecondaryMeshIPNet)
+		if err != nil {
+			return ikto.Config{}, fmt.Errorf("secondary mesh cidr is invalid: %w", err)
+		}
+
+		secondaryOnes, secondaryBits := secondaryIPNet.Mask.Size()
+		if secondaryBits == bits {
+			return ikto.Config{}, fmt.Errorf("secondary mesh network must be of the other address family")
+		}
+		if c.SecondaryHostPrefixLength < secondaryOnes || c.SecondaryHostPrefixLength > secondaryBits {
+			return ikto.Config{}, fmt.Errorf("secondary subnet prefix must be between %d and %d", secondaryOnes, secondaryBits)
+		}
+
+		if c.SecondaryPrivateAddress != "" {
+			secondaryPrivateAddress = net.ParseIP(c.SecondaryPrivateAddress)
+			if secondaryPrivateAddress == nil {
+				return ikto.Config{}, fmt.Errorf("secondary private address is invalid")
+			}
+			if len(secondaryIPNet.IP) == 4 {
+				secondaryPrivateAddress = secondaryPrivateAddress.To4()
+			} else {
+				secondaryPrivateAddress = secondaryPrivateAddress.To16()
+			}
+
+			if !secondaryIPNet.Contains(secondaryPrivateAddress) {
+				return ikto.Config{}, fmt.Errorf("secondary private address is not in secondary mesh network")
+			}
+		} else if c.AddressPath == "" {
+			return ikto.Config{}, fmt.Errorf("address path is required when secondary private address is empty")
+		}
+	} else if c.SecondaryPrivateAddress != "" {
+		return ikto.Config{}, fmt.Errorf("secondary private address requires a secondary mesh cidr")
+	}
+
+	if c.RouteTable < 0 || c.RouteMetric < 0 {
+		return ikto.Config{}, fmt.Errorf("route table and metric must not be negative")
+	}
+	if c.RouteTable == network.ExitRouteTable {
+		return ikto.Config{}, fmt.Errorf("route table %d is reserved for exit nodes", network.ExitRouteTable)
+	}
+
+	if c.ExitNode && c.UseExitNode != "" {
+		return ikto.Config{}, fmt.Errorf("an exit node cannot use another exit node")
+	}
+
+	routes := make([]net.IPNet, 0, len(c.Routes))
+	for _, route := range c.Routes {
+		_, routeIPNet, err := net.ParseCIDR(route)
+		if err != nil {
+			return ikto.Config{}, fmt.Errorf("route %q is invalid: %w", route, err)
+		}
+
+		if network.Overlaps(*routeIPNet, *ipnet) || (secondaryIPNet != nil && network.Overlaps(*routeIPNet, *secondaryIPNet)) {
+			return ikto.Config{}, fmt.Errorf("route %s overlaps the mesh network", routeIPNet.String())
+		}
+		if network.OverlapsAny(*routeIPNet, routes) {
+			return ikto.Config{}, fmt.Errorf("route %s overlaps another route", routeIPNet.String())
+		}
+
+		routes = append(routes, *routeIPNet)
+	}
+
+	heartbeatInterval, err := parseDuration(c.HeartbeatInterval, defaultHeartbeatInterval)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("heartbeat interval is invalid: %w", err)
+	}
+	if heartbeatInterval <= 0 {
+		return ikto.Config{}, fmt.Errorf("heartbeat interval must be positive")
+	}
+
+	peerGracePeriod, err := parseDuration(c.PeerGracePeriod, defaultPeerGracePeriod)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("peer grace period is invalid: %w", err)
+	}
+	if peerGracePeriod <= heartbeatInterval {
+		return ikto.Config{}, fmt.Errorf("peer grace period must be greater than heartbeat interval")
+	}
+
+	resyncInterval, err := parseDuration(c.ResyncInterval, defaultResyncInterval)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("resync interval is invalid: %w", err)
+	}
+
+	reconcileInterval, err := parseDuration(c.ReconcileInterval, defaultReconcileInterval)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("reconcile interval is invalid: %w", err)
+	}
+
+	presharedKeyRotation, err := parseDuration(c.PresharedKeyRotation, defaultPresharedKeyRotation)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("preshared key rotation is invalid: %w", err)
+	}
+
+	keyRotationInterval, err := parseDuration(c.KeyRotationInterval, 0)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("key rotation interval is invalid: %w", err)
+	}
+
+	relayTimeout, err := parseDuration(c.RelayTimeout, defaultRelayTimeout)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("relay timeout is invalid: %w", err)
+	}
+	if relayTimeout != 0 && relayTimeout < minRelayTimeout {
+		return ikto.Config{}, fmt.Errorf("relay timeout must be zero or at least %s", minRelayTimeout)
+	}
+
+	endpointTimeout, err := parseDuration(c.EndpointTimeout, defaultEndpointTimeout)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("endpoint timeout is invalid: %w", err)
+	}
+
+	if err := c.TuningDefaults.Validate(); err != nil {
+		return ikto.Config{}, fmt.Errorf("tuning defaults are invalid: %w", err)
+	}
+	if err := c.Tuning.Validate(); err != nil {
+		return ikto.Config{}, fmt.Errorf("tuning is invalid: %w", err)
+	}
+
+	var authorityPublicKey ed25519.PublicKey
+	nodeKeyPath := c.nodeKeyPath()
+	if c.AuthorityPublicKey != "" {
+		authorityPublicKey, err = authority.ParsePublicKey(c.AuthorityPublicKey)
+		if err != nil {
+			return ikto.Config{}, fmt.Errorf("authority public key is invalid: %w", err)
+		}
+		if c.CertificatePath == "" {
+			return ikto.Config{}, fmt.Errorf("certificate path is required with an authority public key")
+		}
+	}
+
+	if err := types.ValidateLabels(c.Labels); err != nil {
+		return ikto.Config{}, err
+	}
+	if err := types.ValidateAnnotations(c.Annotations); err != nil {
+		return ikto.Config{}, err
+	}
+
+	admissionTimeout, err := parseDuration(c.AdmissionTimeout, defaultAdmissionTimeout)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("admission timeout is invalid: %w", err)
+	}
+
+	autoApproveSelector, err := types.ParseSelector(c.AutoApprove.Selector)
+	if err != nil {
+		return ikto.Config{}, fmt.Errorf("auto approve selector is invalid: %w", err)
+	}
+
+	autoApprove := ikto.AutoApproveRules{
+		Names:    c.AutoApprove.Names,
+		Selector: autoApproveSelector,
+	}
+	for _, key := range c.AutoApprove.PublicKeys {
+		publicKey, err := wgtypes.ParseKey(key)
+		if err != nil {
+			return ikto.Config{}, fmt.Errorf("auto approved public key %q is invalid: %w", key, err)
+		}
+		autoApprove.PublicKeys = append(autoApprove.PublicKeys, types.PublicKey(publicKey))
+	}
+	for _, pattern := range c.AutoApprove.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ikto.Config{}, fmt.Errorf("auto approved name pattern %q is invalid: %w", pattern, err)
+		}
 	}
 
 	return ikto.Config{
@@ -57,27 +352,88 @@ func (c *Config) Validate() (ikto.Config, error) {
 		NatsURL:   c.NatsURL,
 		NatsKV:    c.NatsKV,
 
-		AdvertiseAddress: advertiseAddress,
-		PrivateAddress:   privateAddress,
-		MeshIPNet:        *ipnet,
-		HostPrefixLength: c.HostPrefixLength,
+		AdvertiseAddress:  advertiseAddress,
+		Endpoints:         c.Endpoints,
+		EndpointTimeout:   endpointTimeout,
+		ObservedEndpoints: c.ObservedEndpoints,
+		PrivateAddress:    privateAddress,
+		MeshIPNet:         *ipnet,
+		HostPrefixLength:  c.HostPrefixLength,
+		AddressPath:       c.AddressPath,
+
+		SecondaryMeshIPNet:        secondaryIPNet,
+		SecondaryPrivateAddress:   secondaryPrivateAddress,
+		SecondaryHostPrefixLength: c.SecondaryHostPrefixLength,
+
+		Routes: routes,
+
+		RouteTable:  c.RouteTable,
+		RouteMetric: c.RouteMetric,
+
+		ExitNode:    c.ExitNode,
+		UseExitNode: c.UseExitNode,
+
+		Relay:        c.Relay,
+		RelayTimeout: relayTimeout,
 
 		WGDevName:      c.WGDevName,
 		WGPort:         c.WGPort,
 		PrivateKeyPath: c.PrivateKeyPath,
+		WGNetns:        c.WGNetns,
+
+		TuningDefaults: c.TuningDefaults,
+		Tuning:         c.Tuning,
+
+		HeartbeatInterval: heartbeatInterval,
+		PeerGracePeriod:   peerGracePeriod,
+		ResyncInterval:    resyncInterval,
+
+		ReconcileInterval: reconcileInterval,
+		ReconcileDryRun:   c.ReconcileDryRun,
+
+		PresharedKeys:        c.PresharedKeys,
+		PresharedKeyRotation: presharedKeyRotation,
+
+		KeyRotationInterval: keyRotationInterval,
+
+		AuthorityPublicKey: authorityPublicKey,
+		NodeKeyPath:        nodeKeyPath,
+		CertificatePath:    c.CertificatePath,
+
+		Labels:      c.Labels,
+		Annotations: c.Annotations,
+
+		RequireApproval:  c.RequireApproval,
+		Approvers:        c.Approvers,
+		AdmissionTimeout: admissionTimeout,
+		AutoApprove:      autoApprove,
 	}, nil
 }
 
+func (c *Config) nodeKeyPath() string {
+	if c.NodeKeyPath == "" {
+		return defaultNodeKeyPath
+	}
+
+	return c.NodeKeyPath
+}
+
 func DefaultConfig() *Config {
 	return &Config{
 		Name:             "",
 		NatsCreds:        "",
 		NatsURL:          "nats://",
 		NatsKV:           "ikto-mesh",
-		PrivateKeyPath:   "",
+		PrivateKeyPath:   defaultPrivateKeyPath,
 		AdvertiseAddress: "",
 		MeshIPNet:        "",
+		AddressPath:      "/var/lib/ikto/address",
 		WGDevName:        "wg-ikto",
 		WGPort:           51820,
+
+		HeartbeatInterval: defaultHeartbeatInterval.String(),
+		PeerGracePeriod:   defaultPeerGracePeriod.String(),
+		ResyncInterval:    defaultResyncInterval.String(),
+		ReconcileInterval: defaultReconcileInterval.String(),
 	}
 }
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/pkg/proto"
+)
+
+func NewExitNodeCommand() *cobra.Command {
+	var socket string
+	var clear bool
+	var cmd = &cobra.Command{
+		Use:   "exit-node [peer]",
+		Short: "Route the internet-bound traffic through an exit node",
+		Long: `Route the internet-bound traffic of the local node through the exit node
+with the given name or public key. With --clear, the traffic stops going
+through any exit node.
+		`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if clear {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			peer := ""
+			if !clear {
+				peer = args[0]
+			}
+
+			_, err = client.SetExitNode(context.Background(), &proto.SetExitNodeRequest{
+				Peer: peer,
+			})
+			if err != nil {
+				return err
+			}
+
+			if clear {
+				fmt.Println("Exit node cleared")
+			} else {
+				fmt.Printf("Using exit node %s\n", peer)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+	cmd.Flags().BoolVar(&clear, "clear", false, "Stop using any exit node")
+
+	return cmd
+}
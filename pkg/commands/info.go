@@ -3,40 +3,52 @@ package commands
 import (
 	"context"
 	"fmt"
-	"net"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/valyentdev/ikto/pkg/proto"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/valyentdev/ikto/pkg/types"
 )
 
 func NewInfoCommand() *cobra.Command {
 	var socket string
+	var selector string
 	var cmd = &cobra.Command{
 		Use:   "info",
 		Short: "Print info about the local node",
 		RunE: func(cmd *cobra.Command, args []string) error {
-
-			conn, err := grpc.NewClient("0.0.0.0", grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
-				return net.Dial("unix", socket)
-			}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			labelSelector, err := types.ParseSelector(selector)
 			if err != nil {
 				return err
 			}
 
-			client := proto.NewAdminServiceClient(conn)
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
 
 			infos, err := client.NodeInfo(context.Background(), nil)
 			if err != nil {
 				return err
 			}
 
-			fmt.Println("Local Node:")
-			printPeer(infos.Self)
+			names := make(map[string]string, len(infos.Peers))
+			for _, peer := range infos.Peers {
+				names[peer.PublicKey] = peer.Name
+			}
+
+			if labelSelector.Empty() {
+				fmt.Println("Local Node:")
+				printPeer(infos.Self, names)
+			}
 			fmt.Println("Peers:")
 			for _, peer := range infos.Peers {
-				printPeer(peer)
+				if labelSelector.Matches(peer.Labels) {
+					printPeer(peer, names)
+				}
 			}
 
 			return nil
@@ -44,15 +56,109 @@ func NewInfoCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Only print the peers whose labels match the selector, for example role=hub,region=eu")
 
 	return cmd
 }
 
-func printPeer(peer *proto.Peer) {
+func printPeer(peer *proto.Peer, names map[string]string) {
 	fmt.Printf("Name: %s\n", peer.Name)
 	fmt.Printf("Public Key: %s\n", peer.PublicKey)
-	fmt.Printf("Advertise Address: %s\n", peer.AdvertiseAddr)
+	if peer.AdvertiseAddr != "" {
+		fmt.Printf("Advertise Address: %s\n", peer.AdvertiseAddr)
+	} else if len(peer.CandidateEndpoints) == 0 {
+		fmt.Println("Advertise Address: none (behind NAT)")
+	}
+	if len(peer.CandidateEndpoints) > 1 {
+		fmt.Printf("Candidate Endpoints: %s\n", strings.Join(peer.CandidateEndpoints, ", "))
+	}
+	if peer.Endpoint != "" {
+		fmt.Printf("Endpoint: %s\n", peer.Endpoint)
+	}
+	if len(peer.EndpointFailovers) > 0 {
+		fmt.Println("Endpoint Failovers:")
+		for _, failover := range peer.EndpointFailovers {
+			ago := time.Since(time.Unix(failover.Time, 0)).Truncate(time.Second)
+			fmt.Printf("  %s ago: %s -> %s (%s)\n", ago, failover.From, failover.To, failover.Reason)
+		}
+	}
 	fmt.Printf("Allowed IP: %s\n", peer.AllowedIp)
+	if peer.SecondaryIp != "" {
+		fmt.Printf("Secondary IP: %s\n", peer.SecondaryIp)
+	}
+	if len(peer.Routes) > 0 {
+		fmt.Printf("Routes: %s\n", strings.Join(peer.Routes, ", "))
+	}
 	fmt.Printf("WireGuard Port: %d\n", peer.WgPort)
+	if tuning := formatTuning(peer.Tuning); tuning != "" {
+		fmt.Printf("Tuning: %s\n", tuning)
+	}
+	if peer.ExitNode {
+		fmt.Println("Exit Node: yes")
+	}
+	if peer.Relay {
+		fmt.Println("Relay: yes")
+	}
+	if len(peer.Labels) > 0 {
+		fmt.Printf("Labels: %s\n", formatMetadata(peer.Labels))
+	}
+	if len(peer.Annotations) > 0 {
+		fmt.Printf("Annotations: %s\n", formatMetadata(peer.Annotations))
+	}
+	if peer.PresharedKeyNegotiated != 0 {
+		negotiated := time.Since(time.Unix(peer.PresharedKeyNegotiated, 0)).Truncate(time.Second)
+		fmt.Printf("Preshared Key: yes (negotiated %s ago)\n", negotiated)
+	}
+	if peer.Excluded {
+		fmt.Println("Peered: no (excluded by the topology policies)")
+	}
+	if peer.RelayedBy != "" {
+		relay := peer.RelayedBy
+		if name := names[relay]; name != "" {
+			relay = name
+		}
+		since := time.Since(time.Unix(peer.RelayedSince, 0)).Truncate(time.Second)
+		fmt.Printf("Path: relayed through %s (direct path down for %s)\n", relay, since)
+	}
+	if peer.Alive {
+		fmt.Printf("Alive: yes (last seen %s ago)\n", lastSeen(peer))
+	} else {
+		fmt.Printf("Alive: no (last seen %s ago)\n", lastSeen(peer))
+	}
 	fmt.Println()
 }
+
+func formatTuning(tuning *proto.Tuning) string {
+	if tuning == nil {
+		return ""
+	}
+
+	fields := []string{}
+	if tuning.PersistentKeepalive != 0 {
+		fields = append(fields, fmt.Sprintf("persistent keepalive %ds", tuning.PersistentKeepalive))
+	}
+	if tuning.Mtu != 0 {
+		fields = append(fields, fmt.Sprintf("mtu %d", tuning.Mtu))
+	}
+	if tuning.Fwmark != 0 {
+		fields = append(fields, fmt.Sprintf("fwmark 0x%x", tuning.Fwmark))
+	}
+	if tuning.Txqueuelen != 0 {
+		fields = append(fields, fmt.Sprintf("txqueuelen %d", tuning.Txqueuelen))
+	}
+
+	return strings.Join(fields, ", ")
+}
+
+func formatMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		pairs = append(pairs, key+"="+metadata[key])
+	}
+
+	return strings.Join(pairs, ", ")
+}
+
+func lastSeen(peer *proto.Peer) time.Duration {
+	return time.Since(time.Unix(peer.LastSeen, 0)).Truncate(time.Second)
+}
@@ -1,77 +1,87 @@
 package commands
 
 import (
-	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"net"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/pkg/ikto"
 )
 
-func randomBuffer(size int) []byte {
-	buf := make([]byte, size)
-	_, err := rand.Read(buf)
-	if err != nil {
-		panic(err)
-	}
-	return buf
-}
-
-func RandomSubnet(network net.IPNet, prefix int) net.IPNet {
-	ones, _ := network.Mask.Size()
-
-	randomBuffer := randomBuffer(len(network.IP))
-
-	newIp := make([]byte, len(network.IP))
-	copy(newIp, network.IP)
-
-	// Set the  random bits from the random buffer
-	// on bits from size to prefix
-	for bitIndex := ones; bitIndex < prefix; bitIndex++ {
-		byteIndex := bitIndex / 8
-
-		newIp[byteIndex] |= randomBuffer[byteIndex] & (1 << uint(7-bitIndex%8))
-	}
+const defaultIPv4SubnetPrefix = 24
 
-	return net.IPNet{
-		IP:   newIp,
-		Mask: net.CIDRMask(prefix, 32),
-	}
+// RandomSubnet returns a random subnet of length prefix inside ipNet.
+func RandomSubnet(ipNet net.IPNet, prefix int) net.IPNet {
+	return network.RandomSubnet(ipNet, prefix)
 }
 
 func NewInitCommand() *cobra.Command {
 	var meshIpNet string
 	var subnetPrefix int
+	var allocate bool
+	var privateKeyPath string
+	var generateKey bool
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize configuration for ikto.",
 		Long: `Initialize configuration for ikto by generating a random private address 
 in your mesh subnet.You can specify the mesh subnet and the subnet prefix
 length. Feel free to choose your private address in your mesh subnet.
+Both IPv6 and IPv4 mesh subnets are supported, the subnet prefix length
+defaults to 24 for IPv4 ones. With --allocate, the private address is
+left empty and the agent allocates a free one itself on first start. With
+--generate-key, the private key is generated at the private key path
+unless it exists, the agent otherwise generating it on first start.
 		`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			config := DefaultConfig()
 			_, ipNet, err := net.ParseCIDR(meshIpNet)
 			if err != nil {
-				panic(err)
+				return err
+			}
+
+			ones, bits := ipNet.Mask.Size()
+			if bits == 8*net.IPv4len && !cmd.Flags().Changed("subnet-prefix-length") {
+				subnetPrefix = defaultIPv4SubnetPrefix
+			}
+			if subnetPrefix < ones || subnetPrefix > bits {
+				return fmt.Errorf("subnet prefix length must be between %d and %d for %s", ones, bits, ipNet.String())
 			}
 
 			config.MeshIPNet = ipNet.String()
 
 			config.HostPrefixLength = subnetPrefix
 
-			config.PrivateAddress = RandomSubnet(*ipNet, subnetPrefix).IP.String()
+			if !allocate {
+				config.PrivateAddress = network.HostAddress(RandomSubnet(*ipNet, subnetPrefix)).String()
+			}
+
+			config.PrivateKeyPath = privateKeyPath
+			if generateKey {
+				privateKey, err := ikto.LoadOrGeneratePrivateKey(privateKeyPath)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Public key: %s\n", privateKey.PublicKey().String())
+			}
+
 			bytes, err := json.MarshalIndent(config, "", "  ")
 			if err != nil {
-				panic(err)
+				return err
 			}
-			os.Stdout.Write(append(bytes, '\n'))
+			_, err = os.Stdout.Write(append(bytes, '\n'))
+			return err
 		},
 	}
 
 	cmd.Flags().StringVarP(&meshIpNet, "mesh-ip-net", "m", "fd10::/16", "Mesh IP Net")
 	cmd.Flags().IntVarP(&subnetPrefix, "subnet-prefix-length", "p", 48, "Subnet Prefix")
+	cmd.Flags().BoolVar(&allocate, "allocate", false, "Let the agent allocate a free private address")
+	cmd.Flags().StringVar(&privateKeyPath, "private-key-path", defaultPrivateKeyPath, "Path to the private key")
+	cmd.Flags().BoolVar(&generateKey, "generate-key", false, "Generate the private key if it does not exist")
 
 	return cmd
 }
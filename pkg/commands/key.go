@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/internal/authority"
+	"github.com/valyentdev/ikto/pkg/ikto"
+)
+
+func NewKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "Manage the private key of the local node",
+	}
+
+	cmd.AddCommand(newKeyShowPublicCommand())
+
+	return cmd
+}
+
+func newKeyShowPublicCommand() *cobra.Command {
+	var configPath string
+	var privateKeyPath string
+	var node bool
+	cmd := &cobra.Command{
+		Use:   "show-public",
+		Short: "Print the public key of the local node",
+		Long: `Print the public key matching the private key named in the configuration
+file, or at the given private key path. With --node, print the public key of
+the node key signing the peer records instead, for the mesh authority to
+sign it.
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if privateKeyPath == "" {
+				if configPath == "" {
+					return fmt.Errorf("config path or private key path is required")
+				}
+
+				config, err := readConfig(configPath)
+				if err != nil {
+					return err
+				}
+				privateKeyPath = config.PrivateKeyPath
+				if node {
+					privateKeyPath = config.nodeKeyPath()
+				}
+			}
+
+			if node {
+				nodeKey, err := ikto.LoadSigningKey(privateKeyPath)
+				if errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("node key %s does not exist, start the agent once to generate it", privateKeyPath)
+				}
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(authority.EncodePublicKey(nodeKey.Public().(ed25519.PublicKey)))
+				return nil
+			}
+
+			privateKey, err := ikto.LoadPrivateKey(privateKeyPath)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(privateKey.PublicKey().String())
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Path to the configuration file")
+	cmd.Flags().StringVar(&privateKeyPath, "private-key-path", "", "Path to the private key")
+	cmd.Flags().BoolVar(&node, "node", false, "Print the public key of the node key")
+
+	return cmd
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/pkg/proto"
+)
+
+func NewLabelCommand() *cobra.Command {
+	return newMetadataCommand("label", "labels", func(client proto.AdminServiceClient, req *proto.SetMetadataRequest) (map[string]string, error) {
+		self, err := client.SetLabels(context.Background(), req)
+		if err != nil {
+			return nil, err
+		}
+		return self.Labels, nil
+	})
+}
+
+func NewAnnotateCommand() *cobra.Command {
+	return newMetadataCommand("annotate", "annotations", func(client proto.AdminServiceClient, req *proto.SetMetadataRequest) (map[string]string, error) {
+		self, err := client.SetAnnotations(context.Background(), req)
+		if err != nil {
+			return nil, err
+		}
+		return self.Annotations, nil
+	})
+}
+
+// newMetadataCommand returns a command changing the metadata of the local
+// node with set, which returns the updated metadata.
+func newMetadataCommand(use string, kind string, set func(proto.AdminServiceClient, *proto.SetMetadataRequest) (map[string]string, error)) *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   use + " <key=value|key->...",
+		Short: "Set or remove " + kind + " of the local node",
+		Long: fmt.Sprintf(`Set or remove %[1]s of the local node, published in its peer record.
+"key=value" sets a key and "key-" removes it. The changes last until the agent
+restarts, the %[1]s of the configuration file being applied again.
+		`, kind),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := parseMetadataArgs(args)
+			if err != nil {
+				return err
+			}
+
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			metadata, err := set(client, req)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s%s: %s\n", strings.ToUpper(kind[:1]), kind[1:], formatMetadata(metadata))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
+
+func parseMetadataArgs(args []string) (*proto.SetMetadataRequest, error) {
+	req := &proto.SetMetadataRequest{
+		Set: make(map[string]string),
+	}
+
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			req.Set[key] = value
+			continue
+		}
+
+		key, ok := strings.CutSuffix(arg, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value or key-", arg)
+		}
+		req.Remove = append(req.Remove, key)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/pkg/proto"
+)
+
+func NewLeaveCommand() *cobra.Command {
+	var socket string
+	var removeDevice bool
+	var cmd = &cobra.Command{
+		Use:   "leave",
+		Short: "Remove the local node from the mesh",
+		Long: `Remove the local node from the mesh. The node deletes its own peer record,
+removes every peer from its wireguard device and the agent exits. The
+wireguard device can optionally be removed.
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			_, err = client.Leave(context.Background(), &proto.LeaveRequest{
+				RemoveDevice: removeDevice,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Left the mesh")
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+	cmd.Flags().BoolVar(&removeDevice, "remove-device", false, "Remove the wireguard device after leaving")
+
+	return cmd
+}
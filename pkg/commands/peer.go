@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/pkg/proto"
+)
+
+func NewPeerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "peer",
+		Short: "Manage the admission of the peers",
+	}
+
+	cmd.AddCommand(newPeerPendingCommand())
+	cmd.AddCommand(newPeerApproveCommand())
+
+	return cmd
+}
+
+func newPeerPendingCommand() *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   "pending",
+		Short: "List the nodes waiting for their admission to be approved",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.ListPendingPeers(context.Background(), nil)
+			if err != nil {
+				return err
+			}
+
+			if len(res.Peers) == 0 {
+				fmt.Println("No pending admission requests")
+				return nil
+			}
+
+			for _, pending := range res.Peers {
+				peer := pending.Peer
+				fmt.Printf("Name: %s\n", peer.Name)
+				fmt.Printf("Public Key: %s\n", peer.PublicKey)
+				fmt.Printf("Advertise Address: %s\n", peer.AdvertiseAddr)
+				fmt.Printf("Allowed IP: %s\n", peer.AllowedIp)
+				requested := time.Since(time.Unix(pending.Requested, 0)).Truncate(time.Second)
+				fmt.Printf("Requested: %s ago\n", requested)
+				if pending.Rejection != "" {
+					fmt.Printf("Rejected: %s\n", pending.Rejection)
+				}
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
+
+func newPeerApproveCommand() *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   "approve <name|public key>",
+		Short: "Approve the admission of a node",
+		Long: `Approve the pending admission request of the node with the given name or
+public key, which then joins the mesh.
+		`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.ApprovePeer(context.Background(), &proto.ApprovePeerRequest{
+				Peer: args[0],
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Approved %s (%s) with allowed IP %s\n", res.Peer.Name, res.Peer.PublicKey, res.Peer.AllowedIp)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
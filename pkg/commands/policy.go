@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/internal/authority"
+	"github.com/valyentdev/ikto/pkg/ikto"
+	"github.com/valyentdev/ikto/pkg/proto"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+func NewPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage the topology policies of the mesh",
+	}
+
+	cmd.AddCommand(newPolicyListCommand())
+	cmd.AddCommand(newPolicySetCommand())
+	cmd.AddCommand(newPolicyDeleteCommand())
+
+	return cmd
+}
+
+func newPolicyListCommand() *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the topology policies",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.ListPolicies(context.Background(), nil)
+			if err != nil {
+				return err
+			}
+
+			if len(res.Policies) == 0 {
+				fmt.Println("No topology policies, every node peers with every other node")
+				return nil
+			}
+
+			for _, policy := range res.Policies {
+				fmt.Printf("Name: %s\n", policy.Name)
+				fmt.Printf("Nodes: %s\n", selectorOrAll(policy.Nodes))
+				fmt.Printf("Peers: %s\n", selectorOrAll(policy.Peers))
+				if len(policy.SameLabels) > 0 {
+					fmt.Printf("Same Labels: %s\n", strings.Join(policy.SameLabels, ", "))
+				}
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
+
+func selectorOrAll(selector string) string {
+	if selector == "" {
+		return "all"
+	}
+
+	return selector
+}
+
+func newPolicySetCommand() *cobra.Command {
+	var socket string
+	var nodes string
+	var peers string
+	var sameLabels []string
+	var authorityKeyPath string
+	cmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Create or replace a topology policy",
+		Long: `Create or replace a topology policy. The nodes whose labels match --nodes
+only peer with the nodes matching --peers which have the same value for every
+label of --same. A node to which no policy applies peers with every node, and
+two nodes only peer if both allow it. For example, to make the edge nodes peer
+only with the hubs of their region:
+
+  ikto policy set edges --nodes role=edge --peers role=hub --same region
+
+When the mesh has an authority, the policy must be signed with its key, given
+by --authority-key.
+		`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy := types.Policy{
+				Name:       args[0],
+				Nodes:      nodes,
+				Peers:      peers,
+				SameLabels: sameLabels,
+			}
+			if err := policy.Validate(); err != nil {
+				return err
+			}
+
+			if authorityKeyPath != "" {
+				authorityKey, err := ikto.LoadSigningKey(authorityKeyPath)
+				if err != nil {
+					return err
+				}
+
+				policy, err = authority.SignPolicy(authorityKey, policy)
+				if err != nil {
+					return err
+				}
+			}
+
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			_, err = client.PutPolicy(context.Background(), &proto.Policy{
+				Name:       policy.Name,
+				Nodes:      policy.Nodes,
+				Peers:      policy.Peers,
+				SameLabels: policy.SameLabels,
+				Signature:  policy.Signature,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Policy %s set\n", policy.Name)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+	cmd.Flags().StringVar(&nodes, "nodes", "", "Selector of the nodes the policy applies to, all if empty")
+	cmd.Flags().StringVar(&peers, "peers", "", "Selector of the nodes they peer with, all if empty")
+	cmd.Flags().StringSliceVar(&sameLabels, "same", nil, "Labels whose value must be equal on both nodes")
+	cmd.Flags().StringVar(&authorityKeyPath, "authority-key", "", "Path to the authority key signing the policy")
+
+	return cmd
+}
+
+func newPolicyDeleteCommand() *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a topology policy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			_, err = client.DeletePolicy(context.Background(), &proto.DeletePolicyRequest{
+				Name: args[0],
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Policy %s deleted\n", args[0])
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/valyentdev/ikto/pkg/proto"
+)
+
+func NewReconcileCommand() *cobra.Command {
+	var socket string
+	var dryRun bool
+	var cmd = &cobra.Command{
+		Use:   "reconcile",
+		Short: "Reconcile the wireguard device with the mesh state",
+		Long: `Compare the local wireguard device with the peers known by the agent and
+correct any drift. With --dry-run, the drift is only reported.
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			drift, err := client.Reconcile(context.Background(), &proto.ReconcileRequest{
+				DryRun: dryRun,
+			})
+			if err != nil {
+				return err
+			}
+
+			if !drift.PrivateKey && !drift.ListenPort && !drift.Tuning && len(drift.MissingPeers) == 0 && len(drift.UnknownPeers) == 0 && len(drift.ModifiedPeers) == 0 {
+				fmt.Println("No drift detected")
+				return nil
+			}
+
+			if drift.PrivateKey {
+				fmt.Println("Private key differs")
+			}
+			if drift.ListenPort {
+				fmt.Println("Listen port differs")
+			}
+			if drift.Tuning {
+				fmt.Println("Tuning differs")
+			}
+			for _, key := range drift.MissingPeers {
+				fmt.Printf("Missing peer: %s\n", key)
+			}
+			for _, key := range drift.UnknownPeers {
+				fmt.Printf("Unknown peer: %s\n", key)
+			}
+			for _, key := range drift.ModifiedPeers {
+				fmt.Printf("Modified peer: %s\n", key)
+			}
+
+			if !dryRun {
+				fmt.Println("Drift corrected")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only report drift without correcting it")
+
+	return cmd
+}
@@ -14,5 +14,16 @@ func NewRootCommand() *cobra.Command {
 	root.AddCommand(NewAgentCommand())
 	root.AddCommand(NewInitCommand())
 	root.AddCommand(NewInfoCommand())
+	root.AddCommand(NewLeaveCommand())
+	root.AddCommand(NewStatusCommand())
+	root.AddCommand(NewReconcileCommand())
+	root.AddCommand(NewExitNodeCommand())
+	root.AddCommand(NewRotateKeyCommand())
+	root.AddCommand(NewKeyCommand())
+	root.AddCommand(NewAuthorityCommand())
+	root.AddCommand(NewPeerCommand())
+	root.AddCommand(NewLabelCommand())
+	root.AddCommand(NewAnnotateCommand())
+	root.AddCommand(NewPolicyCommand())
 	return root
 }
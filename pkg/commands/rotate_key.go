@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func NewRotateKeyCommand() *cobra.Command {
+	var socket string
+	var cmd = &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Rotate the WireGuard key of the local node",
+		Long: `Generate a new WireGuard key for the local node and publish it to the mesh.
+The other nodes swap the peer in place, so the node stays reachable during
+the rotation.
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			response, err := client.RotateKey(context.Background(), nil)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("New public key: %s\n", response.PublicKey)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
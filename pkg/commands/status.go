@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewStatusCommand() *cobra.Command {
+	var socket string
+	var cmd = &cobra.Command{
+		Use:   "status",
+		Short: "Print the status of the local agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAdminClient(socket)
+			if err != nil {
+				return err
+			}
+
+			status, err := client.Status(context.Background(), nil)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("NATS: %s\n", status.NatsStatus)
+			fmt.Printf("Watching: %t\n", status.Watching)
+			fmt.Printf("Last Sync: %s\n", time.Unix(status.LastSync, 0).Format(time.RFC3339))
+			fmt.Printf("Resyncs: %d\n", status.Resyncs)
+			if status.LastError != "" {
+				fmt.Printf("Last Error: %s\n", status.LastError)
+			}
+
+			if reconcile := status.Reconcile; reconcile != nil {
+				fmt.Println()
+				fmt.Println("Reconciliation:")
+				fmt.Printf("Runs: %d (%d failed, %d drifted)\n", reconcile.Runs, reconcile.Failures, reconcile.DriftedRuns)
+				fmt.Printf("Last Run: %s\n", time.Unix(reconcile.LastRun, 0).Format(time.RFC3339))
+				fmt.Printf("Private Key Fixes: %d\n", reconcile.PrivateKeyFixes)
+				fmt.Printf("Listen Port Fixes: %d\n", reconcile.ListenPortFixes)
+				fmt.Printf("Tuning Fixes: %d\n", reconcile.TuningFixes)
+				fmt.Printf("Peers Added: %d\n", reconcile.AddedPeers)
+				fmt.Printf("Peers Removed: %d\n", reconcile.RemovedPeers)
+				fmt.Printf("Peers Updated: %d\n", reconcile.UpdatedPeers)
+				if reconcile.LastError != "" {
+					fmt.Printf("Last Error: %s\n", reconcile.LastError)
+				}
+			}
+
+			if status.ExitNode != "" {
+				fmt.Println()
+				if status.ExitNodeActive {
+					fmt.Printf("Exit Node: %s\n", status.ExitNode)
+				} else {
+					fmt.Printf("Exit Node: %s (unavailable)\n", status.ExitNode)
+				}
+			}
+
+			if len(status.RouteConflicts) > 0 {
+				fmt.Println()
+				fmt.Println("Route Conflicts:")
+				for _, conflict := range status.RouteConflicts {
+					fmt.Printf("%s advertised by %s overlaps %s\n", conflict.Route, conflict.Peer, conflict.With)
+				}
+			}
+
+			if len(status.RejectedPeers) > 0 {
+				fmt.Println()
+				fmt.Println("Rejected Peers:")
+				for _, peer := range status.RejectedPeers {
+					fmt.Printf("%s (%s, %s): %s since %s\n", peer.Name, peer.AllowedIp, peer.PublicKey, peer.Reason, time.Unix(peer.Since, 0).Format(time.RFC3339))
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "/tmp/ikto.sock", "Path to the admin socket")
+
+	return cmd
+}
@@ -0,0 +1,273 @@
+package ikto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+var (
+	ErrNoAdmissionRequest = fmt.Errorf("no pending admission request")
+	ErrAmbiguousPeer      = fmt.Errorf("several pending admission requests match")
+	ErrAdmissionTimeout   = fmt.Errorf("admission not approved in time")
+	ErrNotApprover        = fmt.Errorf("the local node is not an approver")
+	ErrSelfApproval       = fmt.Errorf("a node cannot approve itself")
+	ErrBootstrapped       = fmt.Errorf("the mesh was bootstrapped by another node")
+)
+
+// AutoApproveRules approve the requests matching any rule.
+type AutoApproveRules struct {
+	PublicKeys []types.PublicKey
+	// Names are path.Match patterns.
+	Names    []string
+	Selector types.Selector
+}
+
+func (r AutoApproveRules) empty() bool {
+	return len(r.PublicKeys) == 0 && len(r.Names) == 0 && r.Selector.Empty()
+}
+
+func (r AutoApproveRules) match(peer types.Peer) bool {
+	if slices.Contains(r.PublicKeys, peer.PublicKey) {
+		return true
+	}
+
+	for _, pattern := range r.Names {
+		if ok, _ := path.Match(pattern, peer.Name); ok {
+			return true
+		}
+	}
+
+	return !r.Selector.Empty() && r.Selector.Matches(peer.Labels)
+}
+
+// admitted reports whether the local node was approved. With Bootstrap, the
+// local node approves itself unless another node bootstrapped the mesh.
+func (i *Ikto) admitted(ctx context.Context) (bool, error) {
+	_, err := i.store.Approval(ctx, i.self.PublicKey)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, state.ErrKeyNotFound) {
+		return false, fmt.Errorf("failed to get approval: %w", err)
+	}
+	if !i.config.Bootstrap {
+		return false, nil
+	}
+
+	slog.Info("bootstrapping the mesh", "public_key", i.self.PublicKey.String())
+	err = i.store.Bootstrap(ctx, types.Approval{
+		PublicKey: i.self.PublicKey,
+		Name:      i.config.Name,
+		Approver:  i.config.Name,
+		Time:      time.Now(),
+	})
+	if errors.Is(err, state.ErrKeyExists) {
+		return false, ErrBootstrapped
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to bootstrap: %w", err)
+	}
+
+	return true, nil
+}
+
+func (i *Ikto) approve(ctx context.Context, peer types.Peer) error {
+	if !slices.Contains(i.config.Approvers, i.config.Name) {
+		return ErrNotApprover
+	}
+	if peer.PublicKey == i.Self().PublicKey || peer.Name == i.config.Name {
+		return ErrSelfApproval
+	}
+
+	return i.store.PutApproval(ctx, types.Approval{
+		PublicKey: peer.PublicKey,
+		Name:      peer.Name,
+		Approver:  i.config.Name,
+		Time:      time.Now(),
+	})
+}
+
+// approveRotation approves publicKey, which replaces previous, with the
+// approval of the first key of the local node.
+func (i *Ikto) approveRotation(ctx context.Context, previous types.PublicKey, publicKey types.PublicKey) error {
+	approval, err := i.store.Approval(ctx, previous)
+	if err != nil {
+		return fmt.Errorf("failed to get approval: %w", err)
+	}
+	if approval.Previous != nil {
+		approval = *approval.Previous
+	}
+
+	return i.store.PutApproval(ctx, types.Approval{
+		PublicKey: publicKey,
+		Name:      i.config.Name,
+		Approver:  i.config.Name,
+		Time:      time.Now(),
+		Previous:  &approval,
+	})
+}
+
+// requestAdmission waits for the approval of the local node, returning the
+// revision of its record, and withdraws the request after AdmissionTimeout.
+func (i *Ikto) requestAdmission(ctx context.Context) (uint64, error) {
+	if i.config.AdmissionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.config.AdmissionTimeout)
+		defer cancel()
+	}
+
+	err := i.store.RequestAdmission(ctx, i.self)
+	if errors.Is(err, state.ErrKeyExists) {
+		return 0, fmt.Errorf("%w: %w", ErrAddressAlreadyInUse, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to request admission: %w", err)
+	}
+
+	slog.Info("waiting for the admission to be approved", "name", i.self.Name, "public_key", i.self.PublicKey.String(), "allowed_ip", i.self.AllowedIP)
+
+	ticker := time.NewTicker(i.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		peer, revision, err := i.store.GetPeer(ctx, i.self.AllowedIP)
+		if err == nil {
+			if peer.PublicKey != i.self.PublicKey {
+				return 0, ErrAddressAlreadyInUse
+			}
+
+			slog.Info("admission approved", "allowed_ip", i.self.AllowedIP)
+			return revision, nil
+		}
+		if !errors.Is(err, state.ErrKeyNotFound) {
+			slog.Error("failed to check admission", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := i.store.CancelAdmission(context.Background(), i.self.AllowedIP, i.self.PublicKey); err != nil {
+				slog.Error("failed to withdraw admission request", "error", err)
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return 0, fmt.Errorf("%w after %s", ErrAdmissionTimeout, i.config.AdmissionTimeout)
+			}
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *Ikto) PendingPeers(ctx context.Context) ([]state.PendingPeer, error) {
+	return i.store.ListPending(ctx)
+}
+
+// ApprovePeer approves the node named or identified by peer, which then
+// joins the mesh.
+func (i *Ikto) ApprovePeer(ctx context.Context, peer string) (types.Peer, error) {
+	pending, err := i.store.ListPending(ctx)
+	if err != nil {
+		return types.Peer{}, fmt.Errorf("failed to list admission requests: %w", err)
+	}
+
+	match := func(candidate types.Peer) bool {
+		return candidate.Name == peer || candidate.PublicKey.String() == peer
+	}
+
+	var matches []types.Peer
+	for _, request := range pending {
+		if match(request.Peer) {
+			matches = append(matches, request.Peer)
+		}
+	}
+
+	var records []types.Peer
+	for _, rejected := range i.state.RejectedPeers() {
+		if rejected.Reason == state.ErrNotApproved.Error() && match(rejected.Peer) {
+			records = append(records, rejected.Peer)
+		}
+	}
+
+	switch len(matches) + len(records) {
+	case 0:
+		return types.Peer{}, fmt.Errorf("%w: %s", ErrNoAdmissionRequest, peer)
+	case 1:
+	default:
+		return types.Peer{}, fmt.Errorf("%w %s, approve by public key", ErrAmbiguousPeer, peer)
+	}
+
+	if len(records) == 1 {
+		if err := i.approve(ctx, records[0]); err != nil {
+			return types.Peer{}, fmt.Errorf("failed to approve %s: %w", peer, err)
+		}
+
+		slog.Info("approved peer", "name", records[0].Name, "public_key", records[0].PublicKey.String(), "allowed_ip", records[0].AllowedIP)
+		return records[0], nil
+	}
+
+	if err := i.approve(ctx, matches[0]); err != nil {
+		return types.Peer{}, fmt.Errorf("failed to approve %s: %w", peer, err)
+	}
+
+	approved, err := i.store.ApprovePeer(ctx, matches[0].AllowedIP, matches[0].PublicKey)
+	if errors.Is(err, state.ErrKeyNotFound) {
+		return types.Peer{}, fmt.Errorf("%w: %s", ErrNoAdmissionRequest, peer)
+	}
+	if err != nil {
+		return types.Peer{}, fmt.Errorf("failed to approve %s: %w", peer, err)
+	}
+
+	slog.Info("approved peer", "name", approved.Name, "public_key", approved.PublicKey.String(), "allowed_ip", approved.AllowedIP)
+	return approved, nil
+}
+
+func (i *Ikto) autoApprove(ctx context.Context) {
+	ticker := time.NewTicker(i.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pending, err := i.store.ListPending(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("failed to list admission requests", "error", err)
+			}
+			continue
+		}
+
+		for _, request := range pending {
+			if request.Rejection != "" || !i.config.AutoApprove.match(request.Peer) {
+				continue
+			}
+
+			if err := i.approve(ctx, request.Peer); err != nil {
+				slog.Error("failed to approve peer", "error", err, "name", request.Peer.Name, "public_key", request.Peer.PublicKey.String())
+				continue
+			}
+
+			peer, err := i.store.ApprovePeer(ctx, request.Peer.AllowedIP, request.Peer.PublicKey)
+			if errors.Is(err, state.ErrKeyNotFound) {
+				// Approved concurrently by another node.
+				continue
+			}
+			if err != nil {
+				slog.Error("failed to approve peer", "error", err, "name", request.Peer.Name, "public_key", request.Peer.PublicKey.String())
+				continue
+			}
+
+			slog.Info("automatically approved peer", "name", peer.Name, "public_key", peer.PublicKey.String(), "allowed_ip", peer.AllowedIP)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ikto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/authority"
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+func LoadCertificate(path string) (types.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return types.Certificate{}, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	var certificate types.Certificate
+	if err := json.Unmarshal(data, &certificate); err != nil {
+		return types.Certificate{}, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return certificate, nil
+}
+
+// newSigner checks the certificate against the node key, the name, the
+// wireguard key and the configured addresses.
+func newSigner(c *Config, self types.PublicKey) (*authority.Signer, types.Certificate, error) {
+	nodeKey, err := LoadOrGenerateSigningKey(c.NodeKeyPath)
+	if err != nil {
+		return nil, types.Certificate{}, fmt.Errorf("failed to load node key: %w", err)
+	}
+	publicKey := nodeKey.Public().(ed25519.PublicKey)
+
+	certificate, err := LoadCertificate(c.CertificatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, types.Certificate{}, fmt.Errorf("%w: ask the mesh authority to sign the node key %s and the wireguard key %s", err, authority.EncodePublicKey(publicKey), self.String())
+	}
+	if err != nil {
+		return nil, types.Certificate{}, err
+	}
+
+	if err := authority.VerifyCertificate(certificate, c.AuthorityPublicKey, time.Now()); err != nil {
+		return nil, types.Certificate{}, err
+	}
+	if !bytes.Equal(certificate.NodeKey, publicKey) {
+		return nil, types.Certificate{}, fmt.Errorf("%w: certificate issued for another node key", authority.ErrInvalidCertificate)
+	}
+	if certificate.Name != c.Name {
+		return nil, types.Certificate{}, fmt.Errorf("%w: certificate issued to %q", authority.ErrInvalidCertificate, certificate.Name)
+	}
+	if certificate.PublicKey != self {
+		return nil, types.Certificate{}, fmt.Errorf("%w: certificate issued for another wireguard key", authority.ErrInvalidCertificate)
+	}
+
+	addresses := []string{}
+	if c.PrivateAddress != nil {
+		addresses = append(addresses, c.getPrivateCIDR(c.PrivateAddress))
+	}
+	if c.SecondaryPrivateAddress != nil {
+		addresses = append(addresses, fmt.Sprintf("%s/%d", c.SecondaryPrivateAddress.String(), c.SecondaryHostPrefixLength))
+	}
+	for _, address := range addresses {
+		if !authority.Covers(certificate, address) {
+			return nil, types.Certificate{}, fmt.Errorf("%w: %s is outside of the certified prefixes", authority.ErrInvalidCertificate, address)
+		}
+	}
+
+	return authority.NewSigner(nodeKey, certificate), certificate, nil
+}
+
+// RejectedPeers returns the peer records ignored by the verification.
+func (i *Ikto) RejectedPeers() []state.RejectedPeer {
+	return i.state.RejectedPeers()
+}
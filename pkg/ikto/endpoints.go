@@ -0,0 +1,282 @@
+package ikto
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+const (
+	// observedEndpointTTL exceeds the 2 minutes between two handshakes.
+	observedEndpointTTL      = 3 * time.Minute
+	observedEndpointsRefresh = time.Minute
+	punchInterval            = 30 * time.Second
+)
+
+type punchRequest struct {
+	PublicKey types.PublicKey `json:"public_key"`
+}
+
+// endpoints publishes the endpoints of the peers seen by the device and
+// applies those seen by the other nodes to the peers behind a NAT, punching
+// holes when both nodes are behind one.
+type endpoints struct {
+	store    *state.Store
+	device   network.Device
+	routes   *routeTable
+	nc       *nats.Conn
+	prefix   string
+	interval time.Duration
+	self     func() types.Peer
+	peers    func() []types.Peer
+	observed func() []types.ObservedEndpoints
+
+	mutex       sync.Mutex
+	sub         *nats.Subscription
+	published   map[string]string
+	publishedAt time.Time
+	applied     map[types.PublicKey]string
+	punched     map[types.PublicKey]time.Time
+	requested   map[types.PublicKey]bool
+	kick        chan struct{}
+}
+
+func newEndpoints(store *state.Store, device network.Device, routes *routeTable, nc *nats.Conn, bucket string, interval time.Duration, self func() types.Peer, peers func() []types.Peer, observed func() []types.ObservedEndpoints) *endpoints {
+	return &endpoints{
+		store:     store,
+		device:    device,
+		routes:    routes,
+		nc:        nc,
+		prefix:    "ikto.punch." + bucket,
+		interval:  interval,
+		self:      self,
+		peers:     peers,
+		observed:  observed,
+		applied:   make(map[types.PublicKey]string),
+		punched:   make(map[types.PublicKey]time.Time),
+		requested: make(map[types.PublicKey]bool),
+		kick:      make(chan struct{}, 1),
+	}
+}
+
+func (e *endpoints) subject(ip string) string {
+	return e.prefix + "." + hex.EncodeToString([]byte(ip))
+}
+
+func (e *endpoints) subscribe() error {
+	if e.nc == nil {
+		return nil
+	}
+
+	sub, err := e.nc.Subscribe(e.subject(e.self().AllowedIP), e.onPunchRequest)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to hole punching requests: %w", err)
+	}
+	e.sub = sub
+
+	return nil
+}
+
+func (e *endpoints) run(ctx context.Context) {
+	defer func() {
+		if e.sub == nil {
+			return
+		}
+		if err := e.sub.Unsubscribe(); err != nil {
+			slog.Debug("failed to unsubscribe from hole punching requests", "error", err)
+		}
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.kick:
+		}
+
+		if err := e.check(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.Error("failed to check the observed endpoints", "error", err)
+		}
+	}
+}
+
+type punch struct {
+	peer     types.Peer
+	endpoint string
+	signal   bool
+}
+
+func (e *endpoints) check(ctx context.Context, now time.Time) error {
+	device, err := e.device.Device()
+	if err != nil {
+		return err
+	}
+
+	self := e.self()
+	observed := make(map[string]string)
+	handshakes := make(map[types.PublicKey]time.Time, len(device.Peers))
+	for _, wgPeer := range device.Peers {
+		publicKey := types.PublicKey(wgPeer.PublicKey)
+		handshakes[publicKey] = wgPeer.LastHandshakeTime
+		if wgPeer.Endpoint != nil && now.Sub(wgPeer.LastHandshakeTime) < observedEndpointTTL {
+			observed[publicKey.String()] = wgPeer.Endpoint.String()
+		}
+	}
+
+	if !maps.Equal(observed, e.published) || now.Sub(e.publishedAt) >= observedEndpointsRefresh {
+		err := e.store.PutObservedEndpoints(ctx, self.AllowedIP, types.ObservedEndpoints{
+			Name:      self.Name,
+			PublicKey: self.PublicKey,
+			Endpoints: observed,
+			Time:      now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish observed endpoints: %w", err)
+		}
+		e.published = observed
+		e.publishedAt = now
+	}
+
+	type observation struct {
+		endpoint string
+		time     time.Time
+	}
+	candidates := make(map[string]observation)
+	for _, record := range e.observed() {
+		if record.PublicKey == self.PublicKey || now.Sub(record.Time) >= observedEndpointTTL {
+			continue
+		}
+		for publicKey, endpoint := range record.Endpoints {
+			if candidate, ok := candidates[publicKey]; !ok || record.Time.After(candidate.time) {
+				candidates[publicKey] = observation{endpoint: endpoint, time: record.Time}
+			}
+		}
+	}
+
+	e.mutex.Lock()
+	applied := make(map[types.PublicKey]string)
+	punches := []punch{}
+	for _, peer := range e.peers() {
+		if len(peer.Candidates()) > 0 {
+			continue
+		}
+
+		// A peer reached recently keeps its endpoint.
+		if now.Sub(handshakes[peer.PublicKey]) < observedEndpointTTL {
+			if endpoint, ok := e.applied[peer.PublicKey]; ok {
+				applied[peer.PublicKey] = endpoint
+			}
+			continue
+		}
+
+		candidate, ok := candidates[peer.PublicKey.String()]
+		if !ok {
+			continue
+		}
+		applied[peer.PublicKey] = candidate.endpoint
+
+		requested := e.requested[peer.PublicKey]
+		if requested || now.Sub(e.punched[peer.PublicKey]) >= punchInterval {
+			e.punched[peer.PublicKey] = now
+			punches = append(punches, punch{
+				peer:     peer,
+				endpoint: candidate.endpoint,
+				signal:   !requested && len(self.Candidates()) == 0,
+			})
+		}
+	}
+	clear(e.requested)
+	for publicKey := range e.punched {
+		if _, ok := applied[publicKey]; !ok {
+			delete(e.punched, publicKey)
+		}
+	}
+
+	changed := !maps.Equal(applied, e.applied)
+	e.applied = applied
+	e.mutex.Unlock()
+
+	if changed {
+		e.routes.setObservedEndpoints(maps.Clone(applied))
+	}
+
+	for _, punch := range punches {
+		e.punch(self, punch)
+	}
+
+	return nil
+}
+
+func (e *endpoints) punch(self types.Peer, punch punch) {
+	peer := punch.peer
+	slog.Debug("punching hole towards peer", "name", peer.Name, "public_key", peer.PublicKey.String(), "endpoint", punch.endpoint)
+
+	if punch.signal && e.nc != nil {
+		data, err := json.Marshal(punchRequest{PublicKey: self.PublicKey})
+		if err == nil {
+			err = e.nc.Publish(e.subject(peer.AllowedIP), data)
+		}
+		if err != nil {
+			slog.Warn("failed to send hole punching request", "error", err, "public_key", peer.PublicKey.String())
+		}
+	}
+
+	if err := e.device.Probe(peer.PublicKey.WG()); err != nil {
+		slog.Warn("failed to probe peer", "error", err, "public_key", peer.PublicKey.String())
+	}
+}
+
+func (e *endpoints) onPunchRequest(msg *nats.Msg) {
+	var request punchRequest
+	if err := json.Unmarshal(msg.Data, &request); err != nil {
+		slog.Warn("failed to read hole punching request", "error", err)
+		return
+	}
+
+	if !slices.ContainsFunc(e.peers(), func(peer types.Peer) bool {
+		return peer.PublicKey == request.PublicKey
+	}) {
+		slog.Debug("ignoring hole punching request from unknown peer", "public_key", request.PublicKey.String())
+		return
+	}
+
+	e.mutex.Lock()
+	e.requested[request.PublicKey] = true
+	e.mutex.Unlock()
+
+	select {
+	case e.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (i *Ikto) PeerEndpoints() (map[types.PublicKey]string, error) {
+	device, err := i.wg.Device()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make(map[types.PublicKey]string, len(device.Peers))
+	for _, peer := range device.Peers {
+		if peer.Endpoint != nil {
+			endpoints[types.PublicKey(peer.PublicKey)] = peer.Endpoint.String()
+		}
+	}
+
+	return endpoints, nil
+}
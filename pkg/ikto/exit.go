@@ -0,0 +1,41 @@
+package ikto
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+var (
+	ErrUnknownExitNode  = fmt.Errorf("unknown exit node")
+	ErrExitFirewallMark = fmt.Errorf("an exit node cannot be used with a firewall mark")
+)
+
+func isExit(peer types.Peer, exit string) bool {
+	return peer.ExitNode && (peer.Name == exit || peer.PublicKey.String() == exit)
+}
+
+// SetExitNode routes the internet-bound traffic through the exit node named
+// or identified by exit, none if empty.
+func (i *Ikto) SetExitNode(exit string) error {
+	if exit != "" && i.config.tuning().FirewallMark != 0 {
+		return ErrExitFirewallMark
+	}
+	if exit != "" && !slices.ContainsFunc(i.state.ListPeers(), func(peer types.Peer) bool {
+		return isExit(peer, exit)
+	}) {
+		return fmt.Errorf("%w: %s", ErrUnknownExitNode, exit)
+	}
+
+	slog.Info("setting exit node", "exit_node", exit)
+	i.routes.setExit(exit)
+
+	return nil
+}
+
+// ExitNode returns the selected exit node and whether it is active.
+func (i *Ikto) ExitNode() (string, bool) {
+	return i.routes.getExit()
+}
@@ -0,0 +1,187 @@
+package ikto
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+const (
+	// WireGuard renews the handshakes every 2 minutes while traffic flows.
+	staleHandshakeAge    = 150 * time.Second
+	maxEndpointFailovers = 10
+)
+
+type EndpointFailover struct {
+	From   string
+	To     string
+	Time   time.Time
+	Reason string
+}
+
+type endpointState struct {
+	endpoint    string
+	since       time.Time
+	transmitted int64
+	failovers   []EndpointFailover
+}
+
+// failover moves the peers to their next candidate endpoint when the
+// handshake goes stale while the local node keeps sending to them.
+type failover struct {
+	device   network.Device
+	routes   *routeTable
+	timeout  time.Duration
+	interval time.Duration
+	peers    func() []types.Peer
+
+	mutex  sync.Mutex
+	states map[types.PublicKey]*endpointState
+}
+
+func newFailover(device network.Device, routes *routeTable, timeout time.Duration, interval time.Duration, peers func() []types.Peer) *failover {
+	return &failover{
+		device:   device,
+		routes:   routes,
+		timeout:  timeout,
+		interval: interval,
+		peers:    peers,
+		states:   make(map[types.PublicKey]*endpointState),
+	}
+}
+
+func (f *failover) run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := f.check(time.Now()); err != nil {
+			slog.Error("failed to check the endpoints of the peers", "error", err)
+		}
+	}
+}
+
+func (f *failover) check(now time.Time) error {
+	device, err := f.device.Device()
+	if err != nil {
+		return err
+	}
+
+	wgPeers := make(map[wgtypes.Key]wgtypes.Peer, len(device.Peers))
+	for _, wgPeer := range device.Peers {
+		wgPeers[wgPeer.PublicKey] = wgPeer
+	}
+
+	f.mutex.Lock()
+	changed := false
+	seen := make(map[types.PublicKey]bool)
+	for _, peer := range f.peers() {
+		candidates := peer.Candidates()
+		wgPeer, ok := wgPeers[peer.PublicKey.WG()]
+		if len(candidates) < 2 || !ok {
+			continue
+		}
+		seen[peer.PublicKey] = true
+
+		state, ok := f.states[peer.PublicKey]
+		if !ok || !slices.Contains(candidates, state.endpoint) {
+			if ok {
+				changed = true
+			}
+			state = &endpointState{
+				endpoint:    candidates[0],
+				since:       now,
+				transmitted: wgPeer.TransmitBytes,
+			}
+			f.states[peer.PublicKey] = state
+			continue
+		}
+
+		sending := wgPeer.TransmitBytes > state.transmitted
+		state.transmitted = wgPeer.TransmitBytes
+		if !sending {
+			continue
+		}
+
+		var reason string
+		switch {
+		case wgPeer.LastHandshakeTime.Before(state.since) && now.Sub(state.since) >= f.timeout:
+			reason = fmt.Sprintf("no handshake within %s", f.timeout)
+		case !wgPeer.LastHandshakeTime.Before(state.since) && now.Sub(wgPeer.LastHandshakeTime) >= staleHandshakeAge:
+			reason = fmt.Sprintf("last handshake %s ago", now.Sub(wgPeer.LastHandshakeTime).Truncate(time.Second))
+		default:
+			continue
+		}
+
+		next := candidates[(slices.Index(candidates, state.endpoint)+1)%len(candidates)]
+		slog.Info("failing over to the next endpoint of peer", "name", peer.Name, "public_key", peer.PublicKey.String(), "from", state.endpoint, "to", next, "reason", reason)
+
+		state.failovers = append(state.failovers, EndpointFailover{
+			From:   state.endpoint,
+			To:     next,
+			Time:   now,
+			Reason: reason,
+		})
+		if len(state.failovers) > maxEndpointFailovers {
+			state.failovers = state.failovers[1:]
+		}
+		state.endpoint = next
+		state.since = now
+		changed = true
+	}
+
+	for publicKey := range f.states {
+		if !seen[publicKey] {
+			delete(f.states, publicKey)
+			changed = true
+		}
+	}
+
+	selected := make(map[types.PublicKey]string, len(f.states))
+	for publicKey, state := range f.states {
+		selected[publicKey] = state.endpoint
+	}
+	f.mutex.Unlock()
+
+	if changed {
+		f.routes.setSelectedEndpoints(selected)
+	}
+
+	return nil
+}
+
+func (f *failover) failovers() map[types.PublicKey][]EndpointFailover {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	failovers := make(map[types.PublicKey][]EndpointFailover)
+	for publicKey, state := range f.states {
+		if len(state.failovers) > 0 {
+			failovers[publicKey] = slices.Clone(state.failovers)
+		}
+	}
+
+	return failovers
+}
+
+// EndpointFailovers returns the most recent failovers, oldest first.
+func (i *Ikto) EndpointFailovers() map[types.PublicKey][]EndpointFailover {
+	if i.failover == nil {
+		return nil
+	}
+
+	return i.failover.failovers()
+}
@@ -2,39 +2,146 @@ package ikto
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
-	"os"
-	"strings"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
+	"github.com/valyentdev/ikto/internal/authority"
 	"github.com/valyentdev/ikto/internal/network"
 	"github.com/valyentdev/ikto/internal/state"
 	"github.com/valyentdev/ikto/pkg/types"
+	"github.com/vishvananda/netlink"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
 
 type Config struct {
 	Name string
 
+	// AdvertiseAddress is nil when the node is behind a NAT.
 	AdvertiseAddress net.IP
+	// Endpoints are host:port candidates in order of preference.
+	// EndpointTimeout, unless zero, enables their failover.
+	Endpoints       []string
+	EndpointTimeout time.Duration
+	// ObservedEndpoints publishes and uses the endpoints seen by the device.
+	ObservedEndpoints bool
+	// PrivateAddress is allocated when nil and persisted at AddressPath.
 	PrivateAddress   net.IP
 	MeshIPNet        net.IPNet
 	HostPrefixLength int
+	AddressPath      string
+
+	// SecondaryMeshIPNet enables dual-stack addressing when set.
+	SecondaryMeshIPNet        *net.IPNet
+	SecondaryPrivateAddress   net.IP
+	SecondaryHostPrefixLength int
+
+	// Routes are the subnets reachable through the local node.
+	Routes []net.IPNet
+
+	// RouteTable is the main table if zero.
+	RouteTable  int
+	RouteMetric int
+
+	ExitNode bool
+	// UseExitNode is the name or public key of the exit node, if any.
+	UseExitNode string
+
+	Relay bool
+	// RelayTimeout, unless zero, relays the peers without handshake.
+	RelayTimeout time.Duration
 
 	WGDevName      string
 	WGPort         int
 	PrivateKeyPath string
+	// WGNetns is a name under /var/run/netns or a path, empty for the
+	// namespace of the agent.
+	WGNetns string
+
+	// Tuning overrides TuningDefaults and is published in the record.
+	TuningDefaults types.Tuning
+	Tuning         types.Tuning
 
 	NatsCreds string
 	NatsURL   string
 	NatsKV    string
+
+	HeartbeatInterval time.Duration
+	PeerGracePeriod   time.Duration
+	ResyncInterval    time.Duration
+
+	ReconcileInterval time.Duration
+	ReconcileDryRun   bool
+
+	PresharedKeys        bool
+	PresharedKeyRotation time.Duration
+
+	// KeyRotationInterval, unless zero, schedules the key rotations.
+	KeyRotationInterval time.Duration
+
+	// AuthorityPublicKey, when set, requires every record to be signed.
+	AuthorityPublicKey ed25519.PublicKey
+	NodeKeyPath        string
+	CertificatePath    string
+
+	Labels      map[string]string
+	Annotations map[string]string
+
+	// RequireApproval and Approvers must be the same on every node of the mesh.
+	RequireApproval  bool
+	Approvers        []string
+	AdmissionTimeout time.Duration
+	AutoApprove      AutoApproveRules
+	// Bootstrap lets the first node of the mesh approve itself.
+	Bootstrap bool
+}
+
+func (c *Config) meshes() []net.IPNet {
+	meshes := []net.IPNet{c.MeshIPNet}
+	if c.SecondaryMeshIPNet != nil {
+		meshes = append(meshes, *c.SecondaryMeshIPNet)
+	}
+
+	return meshes
+}
+
+func (c *Config) validate() error {
+	if c.HeartbeatInterval <= 0 {
+		return fmt.Errorf("heartbeat interval must be positive")
+	}
+	if c.PeerGracePeriod <= c.HeartbeatInterval {
+		return fmt.Errorf("peer grace period must be greater than heartbeat interval")
+	}
+	if c.UseExitNode != "" && c.tuning().FirewallMark != 0 {
+		return ErrExitFirewallMark
+	}
+	if c.KeyRotationInterval > 0 && c.AuthorityPublicKey != nil {
+		return fmt.Errorf("key rotation interval: %w", ErrCertifiedKey)
+	}
+	if c.RequireApproval && len(c.Approvers) == 0 {
+		return fmt.Errorf("approval requires at least one approver")
+	}
+	if !c.AutoApprove.empty() && !slices.Contains(c.Approvers, c.Name) {
+		return fmt.Errorf("auto approval requires the node to be an approver: %w", ErrNotApprover)
+	}
+
+	return nil
 }
 
-func (c *Config) getPrivateCIDR() string {
-	return fmt.Sprintf("%s/%d", c.PrivateAddress.String(), c.HostPrefixLength)
+func (c *Config) tuning() types.Tuning {
+	return c.TuningDefaults.Override(c.Tuning)
+}
+
+func (c *Config) getPrivateCIDR(address net.IP) string {
+	return fmt.Sprintf("%s/%d", address.String(), c.HostPrefixLength)
 }
 
 type Ikto struct {
@@ -45,38 +152,90 @@ type Ikto struct {
 
 	store *state.Store
 	self  types.Peer
-	state *state.SyncedState
-	wg    *network.WGDevice
+	// certificate is empty without an authority.
+	certificate types.Certificate
+	address     net.IP
+	privateKey  wgtypes.Key
+	revision    uint64
+	state       *state.SyncedState
+	wg          network.Device
+	routes      *routeTable
+
+	// presharedKeys, relays, endpoints and failover are nil unless enabled.
+	presharedKeys *presharedKeys
+	relays        *relays
+	endpoints     *endpoints
+	failover      *failover
+
+	secondaryAddress net.IP
+
+	cancel   context.CancelFunc
+	routines sync.WaitGroup
+
+	reconcileMutex   sync.Mutex
+	reconcileMetrics reconcileMetrics
+
+	// keyMutex guards the key, self and revision during a key rotation.
+	keyMutex sync.RWMutex
+
+	leaveMutex sync.Mutex
+	left       chan struct{}
 }
 
 var ErrAddressAlreadyInUse = fmt.Errorf("address already in use")
+var ErrAlreadyLeft = fmt.Errorf("node already left the mesh")
 
-func NewIkto(c *Config) (*Ikto, error) {
-	privateKeyFile, err := os.ReadFile(c.PrivateKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read private key: %w", err)
+func NewIkto(c *Config, opts ...Option) (*Ikto, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
 	}
 
-	privateKey, err := wgtypes.ParseKey(strings.TrimSpace(string(privateKeyFile)))
+	privateKey, err := LoadOrGeneratePrivateKey(c.PrivateKeyPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %w", err)
+		return nil, err
 	}
 
 	publicKey := privateKey.PublicKey()
 
 	self := types.Peer{
-		Name:             c.Name,
-		PublicKey:        types.PublicKey(publicKey),
-		AdvertiseAddress: c.AdvertiseAddress.String(),
-		AllowedIP:        c.getPrivateCIDR(),
-		WGPort:           c.WGPort,
+		Name:      c.Name,
+		PublicKey: types.PublicKey(publicKey),
+		WGPort:    c.WGPort,
+	}
+	if c.AdvertiseAddress != nil {
+		self.AdvertiseAddress = c.AdvertiseAddress.String()
+	}
+	self.Endpoints = slices.Clone(c.Endpoints)
+	if c.PrivateAddress != nil {
+		self.AllowedIP = c.getPrivateCIDR(c.PrivateAddress)
+	}
+	for _, route := range c.Routes {
+		self.Routes = append(self.Routes, route.String())
 	}
+	self.ExitNode = c.ExitNode
+	self.Relay = c.Relay
+	self.Tuning = c.Tuning
+	self.Labels = maps.Clone(c.Labels)
+	self.Annotations = maps.Clone(c.Annotations)
 
-	slog.Info("Starting with self config", "name", self.Name, "public_key", self.PublicKey.String(), "advertise_address", self.AdvertiseAddress, "allowed_ip", self.AllowedIP, "wg_port", self.WGPort, "wg_dev_name", c.WGDevName)
+	slog.Info("Starting with self config", "name", self.Name, "public_key", self.PublicKey.String(), "advertise_address", self.AdvertiseAddress, "allowed_ip", self.AllowedIP, "dual_stack", c.SecondaryMeshIPNet != nil, "wg_port", self.WGPort, "wg_dev_name", c.WGDevName, "wg_netns", c.WGNetns)
 
-	wg, err := network.New(fmt.Sprintf(c.WGDevName), c.WGPort, privateKey)
+	namespace, err := network.OpenNamespace(c.WGNetns)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create wg service: %w", err)
+		return nil, err
+	}
+
+	wg := o.device
+	if wg == nil {
+		wg, err = network.New(c.WGDevName, c.WGPort, privateKey, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create wg service: %w", err)
+		}
 	}
 
 	err = wg.Ensure()
@@ -89,95 +248,253 @@ func NewIkto(c *Config) (*Ikto, error) {
 		return nil, fmt.Errorf("failed to init wireguard config: %w", err)
 	}
 
-	meshOnes, _ := c.MeshIPNet.Mask.Size()
-
-	err = wg.SetAddr(net.IPNet{
-		IP:   c.PrivateAddress,
-		Mask: net.CIDRMask(meshOnes, len(c.PrivateAddress)*8),
-	})
+	err = wg.SetTuning(c.tuning())
 	if err != nil {
-		return nil, fmt.Errorf("failed to set address: %w", err)
+		return nil, fmt.Errorf("failed to tune wireguard device: %w", err)
 	}
 
-	nc, err := nats.Connect(c.NatsURL, nats.UserCredentials(c.NatsCreds, c.NatsCreds))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	var js jetstream.JetStream
+	var kv jetstream.KeyValue
+
+	nc := o.nc
+	registry := o.registry
+	if nc == nil && (registry == nil || c.PresharedKeys) {
+		nc, err = nats.Connect(c.NatsURL, nats.UserCredentials(c.NatsCreds, c.NatsCreds), nats.MaxReconnects(-1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to nats: %w", err)
+		}
 	}
 
-	js, err := jetstream.New(nc)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create jetstream client: %w", err)
+	if registry == nil {
+		js, err = jetstream.New(nc)
+		if err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("failed to create jetstream client: %w", err)
+		}
+
+		kv, err = js.KeyValue(context.Background(), c.NatsKV)
+		if err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("failed to get key value store: %w", err)
+		}
+
+		registry = state.NewJetStreamRegistry(kv)
 	}
 
-	kv, err := js.KeyValue(context.Background(), c.NatsKV)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get key value store: %w", err)
+	store := state.NewStore(registry)
+
+	var verifyPeer func(data []byte) error
+	var verifyPolicy func(data []byte) error
+	var verifyEndpoints func(data []byte) error
+	var verifyApproval func(data []byte) error
+	var verifyRelays func(data []byte) error
+	var certificate types.Certificate
+	if c.AuthorityPublicKey != nil {
+		var signer *authority.Signer
+		signer, certificate, err = newSigner(c, self.PublicKey)
+		if err != nil {
+			if nc != nil {
+				nc.Close()
+			}
+			return nil, err
+		}
+
+		store.SetSigner(signer.Sign)
+		store.SetEndpointsSigner(signer.SignEndpoints)
+		store.SetApprovalSigner(signer.SignApproval)
+		store.SetRelaysSigner(signer.SignRelays)
+		verifier := authority.NewVerifier(c.AuthorityPublicKey)
+		verifyPeer = verifier.Verify
+		verifyPolicy = verifier.VerifyPolicy
+		verifyEndpoints = verifier.VerifyEndpoints
+		verifyApproval = verifier.VerifyApproval
+		verifyRelays = verifier.VerifyRelays
+		store.SetVerifier(verifyPeer)
+		store.SetPolicyVerifier(verifyPolicy)
 	}
 
-	store := state.NewStore(kv)
+	reserved := append(c.meshes(), c.Routes...)
+	routeManager := o.routeManager
+	if routeManager == nil {
+		routeManager = network.NewRouteManager(c.WGDevName, namespace, c.RouteTable, c.RouteMetric)
+	}
+	// Keep the NAT mappings open unless the mesh sets a keepalive.
+	keepalive := c.TuningDefaults.Keepalive()
+	if keepalive == 0 && len(self.Candidates()) == 0 {
+		keepalive = types.NATKeepaliveInterval
+	}
+	routes := newRouteTable(wg, routeManager, reserved, c.UseExitNode, keepalive)
+
+	var presharedKeys *presharedKeys
+	onPeerPut := routes.put
+	onPeerDelete := routes.delete
+	onInitPeers := routes.replace
+	if c.PresharedKeys {
+		onPeerPut = func(peer types.Peer) {
+			routes.put(peer)
+			presharedKeys.renegotiate(peer.PublicKey.WG())
+		}
+		onPeerDelete = func(peer types.Peer) {
+			routes.delete(peer)
+			presharedKeys.forget(peer.PublicKey.WG())
+		}
+		onInitPeers = func(peers map[string]types.Peer) {
+			routes.replace(peers)
+			presharedKeys.peerChanged()
+		}
+	}
 
 	state := state.New(state.Config{
-		KV:         kv,
-		IgnorePeer: self.PublicKey,
+		Registry:        registry,
+		IgnorePeer:      self.PublicKey,
+		GracePeriod:     c.PeerGracePeriod,
+		ResyncInterval:  c.ResyncInterval,
+		VerifyPeer:      verifyPeer,
+		VerifyPolicy:    verifyPolicy,
+		RequireApproval: c.RequireApproval,
+		VerifyApproval:  verifyApproval,
+		Approvers:       c.Approvers,
+		WatchEndpoints:  c.ObservedEndpoints,
+		VerifyEndpoints: verifyEndpoints,
+		WatchRelays:     c.RelayTimeout > 0,
+		VerifyRelays:    verifyRelays,
+		Labels:          self.Labels,
+
+		OnPeerPut:    onPeerPut,
+		OnPeerDelete: onPeerDelete,
+		OnInitPeers:  onInitPeers,
+	})
 
-		OnPeerPut: func(peer types.Peer) {
-			err := wg.AddPeer(peer)
-			if err != nil {
-				fmt.Printf("failed to add peer: %v", err)
-			}
-		},
-		OnPeerDelete: func(peer types.Peer) {
-			err := wg.RemovePeer(peer.PublicKey.WG())
-			if err != nil {
-				fmt.Printf("failed to remove peer: %v", err)
-			}
-		},
-		OnInitPeers: func(m map[string]types.Peer) {
-			peers := make([]types.Peer, 0, len(m))
-			for _, peer := range m {
-				peers = append(peers, peer)
-			}
+	var failover *failover
+	if c.EndpointTimeout > 0 {
+		failover = newFailover(wg, routes, c.EndpointTimeout, c.HeartbeatInterval, state.ListPeers)
+	}
 
-			err := wg.ReplacePeers(peers)
-			if err != nil {
-				fmt.Printf("failed to replace peers: %v", err)
-			}
-		},
-	})
-	return &Ikto{
+	if c.PresharedKeys {
+		presharedKeys = newPresharedKeys(nc, c.NatsKV, privateKey, wg, c.PresharedKeyRotation, state.ListPeers)
+	}
+
+	if nc != nil {
+		nc.SetDisconnectErrHandler(func(_ *nats.Conn, err error) {
+			slog.Warn("disconnected from nats", "error", err)
+		})
+		nc.SetReconnectHandler(func(nc *nats.Conn) {
+			slog.Info("reconnected to nats, resynchronizing peers", "url", nc.ConnectedUrl())
+			state.Resync()
+		})
+	}
+
+	i := &Ikto{
 		config: *c,
 		nc:     nc,
 		js:     js,
 		kv:     kv,
 
-		store: store,
-		self:  self,
-		state: state,
-		wg:    wg,
-	}, nil
+		store:       store,
+		self:        self,
+		address:     normalizeAddress(c.MeshIPNet, c.PrivateAddress),
+		certificate: certificate,
+		privateKey:  privateKey,
+		state:       state,
+		wg:          wg,
+		routes:      routes,
+
+		presharedKeys: presharedKeys,
+		failover:      failover,
+
+		left: make(chan struct{}),
+	}
+	if c.ObservedEndpoints {
+		i.endpoints = newEndpoints(store, wg, routes, nc, c.NatsKV, c.HeartbeatInterval, i.Self, state.ListPeers, state.ObservedEndpoints)
+	}
+	if c.RelayTimeout > 0 {
+		i.relays = newRelays(store, wg, routes, c.RelayTimeout, c.HeartbeatInterval, i.Self, state.ListPeerStatuses, state.RelayPaths)
+	}
+
+	return i, nil
 }
 
 func (i *Ikto) init() error {
-	previous, revision, err := i.store.GetPeer(context.Background(), i.self.AllowedIP)
-	if err != nil && err != jetstream.ErrKeyNotFound {
-		return fmt.Errorf("failed to get self: % w", err)
+	ctx := context.Background()
+
+	if err := i.resumeRotation(ctx); err != nil {
+		return fmt.Errorf("failed to resume key rotation: %w", err)
 	}
-	if err == jetstream.ErrKeyNotFound {
-		_, err := i.store.CreatePeer(context.Background(), i.self)
+
+	// Reserve the secondary address before the record is visible.
+	var secondaryCreated bool
+	if i.config.SecondaryMeshIPNet != nil {
+		created, err := i.initSecondary(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to create self: %w", err)
+			return fmt.Errorf("failed to reserve secondary address: %w", err)
+		}
+		secondaryCreated = created
+	}
+
+	err := i.initPrimary(ctx)
+	if err != nil {
+		if secondaryCreated {
+			releaseErr := i.store.ReleaseAddress(ctx, i.self.SecondaryIP, i.self.PublicKey)
+			if releaseErr != nil {
+				slog.Error("failed to release secondary address", "error", releaseErr, "secondary_ip", i.self.SecondaryIP)
+			}
 		}
+		return err
+	}
+
+	if i.config.PrivateAddress != nil && (i.config.SecondaryMeshIPNet == nil || i.config.SecondaryPrivateAddress != nil) {
 		return nil
 	}
 
+	return i.saveAddresses()
+}
+
+func (i *Ikto) claim() error {
+	previous, revision, err := i.store.GetPeer(context.Background(), i.self.AllowedIP)
+	if err != nil && !errors.Is(err, state.ErrKeyNotFound) {
+		return fmt.Errorf("failed to get self: %w", err)
+	}
+	if errors.Is(err, state.ErrKeyNotFound) {
+		return i.createSelf(context.Background())
+	}
+
 	if previous.PublicKey != i.self.PublicKey {
 		return ErrAddressAlreadyInUse
 	}
 
-	_, err = i.store.UpdatePeer(context.Background(), i.self, revision)
+	revision, err = i.store.UpdatePeer(context.Background(), i.self, revision)
 	if err != nil {
 		return fmt.Errorf("failed to update self: %w", err)
 	}
+	i.revision = revision
+
+	return nil
+}
+
+func (i *Ikto) createSelf(ctx context.Context) error {
+	if i.config.RequireApproval {
+		admitted, err := i.admitted(ctx)
+		if err != nil {
+			return err
+		}
+		if !admitted {
+			revision, err := i.requestAdmission(ctx)
+			if err != nil {
+				return err
+			}
+			i.revision = revision
+			return nil
+		}
+	}
+
+	revision, err := i.store.CreatePeer(ctx, i.self)
+	if errors.Is(err, state.ErrKeyExists) {
+		return fmt.Errorf("%w: %w", ErrAddressAlreadyInUse, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create self: %w", err)
+	}
+	i.revision = revision
 
 	return nil
 }
@@ -187,28 +504,294 @@ func (i *Ikto) Start() error {
 		return fmt.Errorf("failed to init: %w", err)
 	}
 
-	err := i.state.Start(context.Background())
+	// The peers are reached through the routes, not the address mask.
+	_, meshBits := i.config.MeshIPNet.Mask.Size()
+
+	err := i.wg.SetAddr(net.IPNet{
+		IP:   i.address,
+		Mask: net.CIDRMask(i.config.HostPrefixLength, meshBits),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set address: %w", err)
+	}
+
+	if i.secondaryAddress != nil {
+		_, secondaryBits := i.config.SecondaryMeshIPNet.Mask.Size()
+
+		err = i.wg.SetAddr(net.IPNet{
+			IP:   i.secondaryAddress,
+			Mask: net.CIDRMask(i.config.SecondaryHostPrefixLength, secondaryBits),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set secondary address: %w", err)
+		}
+	}
+
+	families := routeFamilies(i.config.Routes)
+	if i.config.ExitNode || i.config.Relay {
+		families = []int{netlink.FAMILY_V4, netlink.FAMILY_V6}
+	}
+
+	if i.config.ExitNode {
+		err = i.wg.SetMasquerade(i.config.meshes(), true)
+		if err != nil {
+			return fmt.Errorf("failed to masquerade exit traffic: %w", err)
+		}
+	}
+
+	for _, family := range families {
+		err = i.wg.EnableForwarding(family)
+		if err != nil {
+			return fmt.Errorf("failed to enable forwarding: %w", err)
+		}
+	}
+
+	err = i.state.Start(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to start state: %w", err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	i.cancel = cancel
+
+	if i.presharedKeys != nil {
+		if err := i.presharedKeys.start(); err != nil {
+			cancel()
+			return err
+		}
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.presharedKeys.run(ctx)
+		}()
+	}
+
+	i.routines.Add(3)
+	go func() {
+		defer i.routines.Done()
+		i.heartbeat(ctx)
+	}()
+	go func() {
+		defer i.routines.Done()
+		i.reap(ctx)
+	}()
+	go func() {
+		defer i.routines.Done()
+		i.watchSelf(ctx)
+	}()
+
+	if i.config.ReconcileInterval > 0 {
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.reconcileLoop(ctx)
+		}()
+	}
+
+	if i.config.KeyRotationInterval > 0 {
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.rotateKeyLoop(ctx)
+		}()
+	}
+
+	if i.endpoints != nil {
+		if err := i.endpoints.subscribe(); err != nil {
+			cancel()
+			return err
+		}
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.endpoints.run(ctx)
+		}()
+	}
+
+	if i.failover != nil {
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.failover.run(ctx)
+		}()
+	}
+
+	if i.relays != nil {
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.relays.run(ctx)
+		}()
+	}
+
+	if !i.config.AutoApprove.empty() {
+		i.routines.Add(1)
+		go func() {
+			defer i.routines.Done()
+			i.autoApprove(ctx)
+		}()
+	}
+
 	return nil
 }
 
+func (i *Ikto) stopRoutines() {
+	if i.cancel != nil {
+		i.cancel()
+	}
+	i.routines.Wait()
+}
+
 func (i *Ikto) Stop() {
 	slog.Info("stopping")
+	i.stopRoutines()
 	i.state.Stop()
-	i.nc.Close()
+	if err := i.routes.routeManager.Cleanup(); err != nil {
+		slog.Error("failed to remove routes", "error", err)
+	}
+	if i.nc != nil {
+		i.nc.Close()
+	}
 }
 
-func (i *Ikto) Leave() {
-	slog.Error("leaving network")
+// Leave deletes the peer record of the local node and removes its peers,
+// and its device if removeDevice is true.
+func (i *Ikto) Leave(ctx context.Context, removeDevice bool) error {
+	i.leaveMutex.Lock()
+	defer i.leaveMutex.Unlock()
+
+	select {
+	case <-i.left:
+		return ErrAlreadyLeft
+	default:
+	}
+
+	slog.Info("leaving network", "allowed_ip", i.self.AllowedIP, "revision", i.revision)
+
+	deleted := i.state.NotifyDelete(state.PeerKey(i.self.AllowedIP))
+
+	err := i.store.DeletePeer(ctx, i.self.AllowedIP, i.revision)
+	if err != nil {
+		return fmt.Errorf("failed to delete self: %w", err)
+	}
+
+	select {
+	case <-deleted:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for self deletion: %w", ctx.Err())
+	}
+
+	i.stopRoutines()
+
+	err = i.store.DeleteHeartbeat(ctx, i.self.AllowedIP)
+	if err != nil {
+		slog.Error("failed to delete heartbeat", "error", err)
+	}
+
+	if i.config.RequireApproval {
+		err = i.store.DeleteApproval(ctx, i.self.PublicKey)
+		if err != nil {
+			slog.Error("failed to delete approval", "error", err)
+		}
+	}
+
+	err = i.store.DeleteObservedEndpoints(ctx, i.self.AllowedIP)
+	if err != nil {
+		slog.Error("failed to delete observed endpoints", "error", err)
+	}
+
+	err = i.store.DeleteRelayPaths(ctx, i.self.AllowedIP)
+	if err != nil {
+		slog.Error("failed to delete relays", "error", err)
+	}
+
+	if i.self.SecondaryIP != "" {
+		err = i.store.ReleaseAddress(ctx, i.self.SecondaryIP, i.self.PublicKey)
+		if err != nil {
+			slog.Error("failed to release secondary address", "error", err)
+		}
+	}
+
+	return i.teardown(removeDevice)
+}
+
+func (i *Ikto) teardown(removeDevice bool) error {
+	i.state.Stop()
+
+	err := i.wg.ReplacePeers(nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove peers: %w", err)
+	}
+
+	err = i.routes.routeManager.Cleanup()
+	if err != nil {
+		return fmt.Errorf("failed to remove routes: %w", err)
+	}
+
+	err = i.wg.SetDefaultRoute(false)
+	if err != nil {
+		return fmt.Errorf("failed to remove default route: %w", err)
+	}
+
+	if i.config.ExitNode {
+		err = i.wg.SetMasquerade(i.config.meshes(), false)
+		if err != nil {
+			return fmt.Errorf("failed to remove masquerading: %w", err)
+		}
+	}
+
+	if removeDevice {
+		err = i.wg.Remove()
+		if err != nil {
+			return fmt.Errorf("failed to remove wireguard device: %w", err)
+		}
+	}
+
+	slog.Info("left network")
+	close(i.left)
+
+	return nil
+}
+
+// Left returns a channel that is closed once the node has left the mesh.
+func (i *Ikto) Left() <-chan struct{} {
+	return i.left
 }
 
 func (i *Ikto) Self() types.Peer {
+	i.keyMutex.RLock()
+	defer i.keyMutex.RUnlock()
+
 	return i.self
 }
 
+// Status reports the health of NATS and of the synchronization.
+type Status struct {
+	NatsStatus string
+	Connected  bool
+	Sync       state.Status
+}
+
+func (i *Ikto) Status() Status {
+	if i.nc == nil {
+		return Status{
+			NatsStatus: "NOT_USED",
+			Sync:       i.state.Status(),
+		}
+	}
+
+	return Status{
+		NatsStatus: i.nc.Status().String(),
+		Connected:  i.nc.IsConnected(),
+		Sync:       i.state.Status(),
+	}
+}
+
+// RouteConflicts returns the advertised routes currently ignored.
+func (i *Ikto) RouteConflicts() []RouteConflict {
+	return i.routes.getConflicts()
+}
+
 func (i *Ikto) Peers() []types.Peer {
 	return i.state.ListPeers()
 }
@@ -0,0 +1,1608 @@
+package ikto
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/authority"
+	"github.com/valyentdev/ikto/internal/natstest"
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/internal/psk"
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func newSelf(t *testing.T, allowedIP string) types.Peer {
+	t.Helper()
+
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return types.Peer{
+		Name:             "node",
+		PublicKey:        types.PublicKey(key.PublicKey()),
+		AdvertiseAddress: "192.0.2.1",
+		AllowedIP:        allowedIP,
+		WGPort:           51820,
+	}
+}
+
+func TestInitAddressConflict(t *testing.T) {
+	s := natstest.StartServer(t)
+	store := state.NewStore(state.NewJetStreamRegistry(natstest.KeyValue(t, s)))
+
+	config := Config{PrivateAddress: net.ParseIP("fd10:1::"), HostPrefixLength: 48}
+
+	first := &Ikto{config: config, store: store, self: newSelf(t, "fd10:1::/48")}
+	if err := first.init(); err != nil {
+		t.Fatalf("failed to init first node: %v", err)
+	}
+	created := first.revision
+
+	if err := first.init(); err != nil {
+		t.Fatalf("failed to init first node again: %v", err)
+	}
+	if first.revision <= created {
+		t.Fatalf("expected self to be updated, revision went from %d to %d", created, first.revision)
+	}
+
+	second := &Ikto{config: config, store: store, self: newSelf(t, "fd10:1::/48")}
+	if err := second.init(); !errors.Is(err, ErrAddressAlreadyInUse) {
+		t.Fatalf("expected ErrAddressAlreadyInUse, got %v", err)
+	}
+}
+
+func newConfig(t *testing.T, name string, meshCIDR string, prefix int, address string) *Config {
+	t.Helper()
+
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "private.key")
+	if err := os.WriteFile(keyPath, []byte(key.String()), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	_, mesh, err := net.ParseCIDR(meshCIDR)
+	if err != nil {
+		t.Fatalf("failed to parse mesh cidr: %v", err)
+	}
+
+	return &Config{
+		Name:              name,
+		AdvertiseAddress:  net.ParseIP("192.0.2.1"),
+		PrivateAddress:    net.ParseIP(address),
+		MeshIPNet:         *mesh,
+		HostPrefixLength:  prefix,
+		AddressPath:       filepath.Join(dir, "address"),
+		WGDevName:         "wg-ikto",
+		WGPort:            51820,
+		PrivateKeyPath:    keyPath,
+		HeartbeatInterval: time.Second,
+		PeerGracePeriod:   time.Minute,
+	}
+}
+
+func startNode(t *testing.T, registry state.Registry, config *Config, opts ...Option) (*Ikto, *network.FakeDevice) {
+	t.Helper()
+
+	node, device, _ := startRoutedNode(t, registry, config, opts...)
+	return node, device
+}
+
+func startRoutedNode(t *testing.T, registry state.Registry, config *Config, opts ...Option) (*Ikto, *network.FakeDevice, *network.FakeRouteManager) {
+	t.Helper()
+
+	device := network.NewFakeDevice(config.WGDevName, config.WGPort, wgtypes.Key{})
+	routeManager := network.NewFakeRouteManager()
+
+	opts = append([]Option{WithDevice(device), WithRouteManager(routeManager), WithRegistry(registry)}, opts...)
+	node, err := NewIkto(config, opts...)
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	t.Cleanup(node.Stop)
+
+	return node, device, routeManager
+}
+
+func newNode(t *testing.T, registry state.Registry, name string, address string) (*Ikto, *network.FakeDevice) {
+	t.Helper()
+
+	return startNode(t, registry, newConfig(t, name, "fd10::/16", 48, address))
+}
+
+func waitForPeers(t *testing.T, device *network.FakeDevice, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(device.Peers()) == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %d peers, got %d", count, len(device.Peers()))
+}
+
+func TestMeshWithFakeDevices(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	a, deviceA := newNode(t, registry, "a", "fd10:a::")
+	b, deviceB := newNode(t, registry, "b", "fd10:b::")
+
+	waitForPeers(t, deviceA, 1)
+	waitForPeers(t, deviceB, 1)
+
+	if got := deviceA.Peers()[0].PublicKey; got != b.Self().PublicKey.WG() {
+		t.Fatalf("expected a to peer with b, got %s", got)
+	}
+	if addrs := deviceA.Addrs(); len(addrs) != 1 || addrs[0].String() != "fd10:a::/48" {
+		t.Fatalf("unexpected addresses on a: %v", addrs)
+	}
+
+	err := a.Leave(context.Background(), true)
+	if err != nil {
+		t.Fatalf("failed to leave: %v", err)
+	}
+
+	waitForPeers(t, deviceB, 0)
+	if len(deviceA.Peers()) != 0 || deviceA.Exists() {
+		t.Fatal("expected a's device to be emptied and removed")
+	}
+
+	select {
+	case <-a.Left():
+	default:
+		t.Fatal("expected a to have left")
+	}
+
+	if err := a.Leave(context.Background(), false); !errors.Is(err, ErrAlreadyLeft) {
+		t.Fatalf("expected ErrAlreadyLeft, got %v", err)
+	}
+}
+
+func TestAllocateAddress(t *testing.T) {
+	ctx := context.Background()
+	registry := state.NewMemoryRegistry()
+	store := state.NewStore(registry)
+
+	// Leave a single free /124 in the mesh.
+	_, mesh, _ := net.ParseCIDR("fd10::/120")
+	free := "fd10::70/124"
+	for i := range 16 {
+		subnet := net.IPNet{IP: slices.Clone(mesh.IP), Mask: net.CIDRMask(124, 128)}
+		subnet.IP[15] = byte(i << 4)
+		if subnet.String() == free {
+			continue
+		}
+		if _, err := store.CreatePeer(ctx, newSelf(t, subnet.String())); err != nil {
+			t.Fatalf("failed to create peer: %v", err)
+		}
+	}
+
+	config := newConfig(t, "auto", "fd10::/120", 124, "")
+	config.PrivateAddress = nil
+
+	node, device := startNode(t, registry, config)
+	if got := node.Self().AllowedIP; got != free {
+		t.Fatalf("expected %s to be allocated, got %s", free, got)
+	}
+	if addrs := device.Addrs(); len(addrs) != 1 || addrs[0].String() != "fd10::70/124" {
+		t.Fatalf("unexpected addresses: %v", addrs)
+	}
+
+	saved, err := os.ReadFile(config.AddressPath)
+	if err != nil || strings.TrimSpace(string(saved)) != "fd10::70" {
+		t.Fatalf("expected address to be persisted, got %q (%v)", saved, err)
+	}
+
+	node.Stop()
+
+	restarted, _ := startNode(t, registry, config)
+	if got := restarted.Self().AllowedIP; got != free {
+		t.Fatalf("expected restarted node to keep %s, got %s", free, got)
+	}
+
+	crowded := newConfig(t, "crowded", "fd10::/120", 124, "")
+	crowded.PrivateAddress = nil
+	other, err := NewIkto(crowded, WithDevice(network.NewFakeDevice("wg-ikto", 51820, wgtypes.Key{})), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if err := other.Start(); !errors.Is(err, ErrNoFreeAddress) {
+		t.Fatalf("expected ErrNoFreeAddress, got %v", err)
+	}
+}
+
+func TestIPv4Mesh(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	_, deviceA := startNode(t, registry, newConfig(t, "a", "10.0.0.0/8", 24, "10.0.1.1"))
+	config := newConfig(t, "b", "10.0.0.0/8", 24, "")
+	config.PrivateAddress = nil
+	b, deviceB := startNode(t, registry, config)
+
+	waitForPeers(t, deviceA, 1)
+	waitForPeers(t, deviceB, 1)
+
+	if addrs := deviceA.Addrs(); len(addrs) != 1 || addrs[0].String() != "10.0.1.1/24" {
+		t.Fatalf("unexpected addresses on a: %v", addrs)
+	}
+
+	address, allocated, err := net.ParseCIDR(b.Self().AllowedIP)
+	if err != nil || address.To4() == nil {
+		t.Fatalf("expected an IPv4 subnet to be allocated, got %q", b.Self().AllowedIP)
+	}
+	if address.Equal(allocated.IP) {
+		t.Fatalf("expected a host address to be allocated, got the network address %s", address)
+	}
+
+	allowedIPs := deviceB.Peers()[0].AllowedIPs
+	if len(allowedIPs) != 1 || allowedIPs[0].String() != "10.0.1.0/24" {
+		t.Fatalf("unexpected allowed ips for a on b: %v", allowedIPs)
+	}
+}
+
+func withSecondary(t *testing.T, config *Config, meshCIDR string, prefix int) *Config {
+	t.Helper()
+
+	_, mesh, err := net.ParseCIDR(meshCIDR)
+	if err != nil {
+		t.Fatalf("failed to parse secondary mesh cidr: %v", err)
+	}
+
+	config.SecondaryMeshIPNet = mesh
+	config.SecondaryHostPrefixLength = prefix
+	return config
+}
+
+func TestDualStackMesh(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	configA := withSecondary(t, newConfig(t, "a", "fd10::/64", 128, "fd10::1"), "10.0.0.0/16", 32)
+	configA.SecondaryPrivateAddress = net.ParseIP("10.0.0.1")
+	a, deviceA := startNode(t, registry, configA)
+
+	configB := withSecondary(t, newConfig(t, "b", "fd10::/64", 128, "fd10::2"), "10.0.0.0/16", 32)
+	b, deviceB := startNode(t, registry, configB)
+
+	waitForPeers(t, deviceA, 1)
+	waitForPeers(t, deviceB, 1)
+
+	if addrs := deviceA.Addrs(); len(addrs) != 2 || addrs[0].String() != "fd10::1/128" || addrs[1].String() != "10.0.0.1/32" {
+		t.Fatalf("unexpected addresses on a: %v", addrs)
+	}
+
+	_, secondary, err := net.ParseCIDR(b.Self().SecondaryIP)
+	if err != nil || secondary.IP.To4() == nil || secondary.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatalf("expected a free IPv4 address to be allocated, got %q", b.Self().SecondaryIP)
+	}
+
+	saved, err := os.ReadFile(configB.AddressPath)
+	if err != nil || !strings.Contains(string(saved), secondary.IP.String()) {
+		t.Fatalf("expected secondary address to be persisted, got %q (%v)", saved, err)
+	}
+
+	allowedIPs := sortedStrings(deviceB.Peers()[0].AllowedIPs)
+	if !slices.Equal(allowedIPs, []string{"10.0.0.1/32", "fd10::1/128"}) {
+		t.Fatalf("unexpected allowed ips for a on b: %v", allowedIPs)
+	}
+
+	if err := a.Leave(context.Background(), false); err != nil {
+		t.Fatalf("failed to leave: %v", err)
+	}
+
+	configC := withSecondary(t, newConfig(t, "c", "fd10::/64", 128, "fd10::3"), "10.0.0.0/16", 32)
+	configC.SecondaryPrivateAddress = net.ParseIP("10.0.0.1")
+	startNode(t, registry, configC)
+}
+
+func TestSecondaryAddressConflict(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	configA := withSecondary(t, newConfig(t, "a", "fd10::/64", 128, "fd10::1"), "10.0.0.0/16", 32)
+	configA.SecondaryPrivateAddress = net.ParseIP("10.0.0.1")
+	startNode(t, registry, configA)
+
+	configB := withSecondary(t, newConfig(t, "b", "fd10::/64", 128, "fd10::2"), "10.0.0.0/16", 32)
+	configB.SecondaryPrivateAddress = net.ParseIP("10.0.0.1")
+	b, err := NewIkto(configB, WithDevice(network.NewFakeDevice("wg-ikto", 51820, wgtypes.Key{})), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if err := b.Start(); !errors.Is(err, ErrAddressAlreadyInUse) {
+		t.Fatalf("expected ErrAddressAlreadyInUse, got %v", err)
+	}
+
+	if _, _, err := state.NewStore(registry).GetPeer(context.Background(), "fd10::2/128"); !errors.Is(err, state.ErrKeyNotFound) {
+		t.Fatalf("expected no peer record for b, got %v", err)
+	}
+}
+
+func sortedStrings(ipnets []net.IPNet) []string {
+	s := make([]string, 0, len(ipnets))
+	for _, ipnet := range ipnets {
+		s = append(s, ipnet.String())
+	}
+	slices.Sort(s)
+	return s
+}
+
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %s", description)
+}
+
+func peerAllowedIPs(device *network.FakeDevice, publicKey types.PublicKey) []string {
+	for _, peer := range device.Peers() {
+		if peer.PublicKey == publicKey.WG() {
+			return sortedStrings(peer.AllowedIPs)
+		}
+	}
+
+	return nil
+}
+
+func TestAdvertisedRoutes(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	configA := newConfig(t, "a", "fd10::/64", 128, "fd10::1")
+	configA.Routes = []net.IPNet{{IP: net.ParseIP("192.168.1.0").To4(), Mask: net.CIDRMask(24, 32)}}
+	a, deviceA := startNode(t, registry, configA)
+	_, deviceC, routesC := startRoutedNode(t, registry, newConfig(t, "c", "fd10::/64", 128, "fd10::3"))
+
+	if !deviceA.Forwarding(network.Family(net.ParseIP("192.168.1.0"))) {
+		t.Fatalf("expected forwarding to be enabled on the advertising node")
+	}
+
+	waitFor(t, "route of a on c", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceC, a.Self().PublicKey), []string{"192.168.1.0/24", "fd10::1/128"})
+	})
+	if routes := sortedStrings(routesC.Routes()); !slices.Equal(routes, []string{"192.168.1.0/24", "fd10::1/128"}) {
+		t.Fatalf("unexpected routes on c: %v", routes)
+	}
+
+	configB := newConfig(t, "b", "fd10::/64", 128, "fd10::2")
+	configB.Routes = []net.IPNet{{IP: net.ParseIP("192.168.1.128").To4(), Mask: net.CIDRMask(25, 32)}}
+	b, _ := startNode(t, registry, configB)
+
+	waitFor(t, "conflicting routes to be rejected on c", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceC, a.Self().PublicKey), []string{"fd10::1/128"}) &&
+			slices.Equal(peerAllowedIPs(deviceC, b.Self().PublicKey), []string{"fd10::2/128"}) &&
+			len(routesC.Routes()) == 2
+	})
+
+	if err := b.Leave(context.Background(), false); err != nil {
+		t.Fatalf("failed to leave: %v", err)
+	}
+
+	waitFor(t, "route of a to be restored on c", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceC, a.Self().PublicKey), []string{"192.168.1.0/24", "fd10::1/128"}) &&
+			len(routesC.Routes()) == 2
+	})
+}
+
+func TestResolveRoutes(t *testing.T) {
+	_, mesh, _ := net.ParseCIDR("fd10::/64")
+	peers := []types.Peer{
+		{Name: "a", AllowedIP: "fd10::1/128", Routes: []string{"10.1.0.0/16", "10.2.0.0/16"}},
+		{Name: "b", AllowedIP: "fd10::2/128", Routes: []string{"10.2.1.0/24", "fd10::/120"}},
+		{Name: "c", AllowedIP: "fd10::3/128", Routes: []string{"10.3.0.0/16"}},
+	}
+
+	effective, conflicts := resolveRoutes([]net.IPNet{*mesh}, peers)
+
+	expected := map[string][]string{
+		"a": {"10.1.0.0/16"},
+		"b": nil,
+		"c": {"10.3.0.0/16"},
+	}
+	for _, peer := range effective {
+		if !slices.Equal(peer.Routes, expected[peer.Name]) {
+			t.Errorf("unexpected routes for %s: %v", peer.Name, peer.Routes)
+		}
+	}
+
+	if len(conflicts) != 3 {
+		t.Fatalf("expected 3 conflicts, got %v", conflicts)
+	}
+}
+
+func TestExitNode(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	configA := newConfig(t, "a", "fd10::/64", 128, "fd10::1")
+	configA.ExitNode = true
+	a, deviceA := startNode(t, registry, configA)
+
+	configB := newConfig(t, "b", "fd10::/64", 128, "fd10::2")
+	configB.UseExitNode = "a"
+	b, deviceB, routesB := startRoutedNode(t, registry, configB)
+
+	if masquerade := deviceA.Masquerade(); len(masquerade) != 1 || masquerade[0].String() != "fd10::/64" {
+		t.Fatalf("unexpected masqueraded sources on the exit node: %v", masquerade)
+	}
+	if !deviceA.Forwarding(network.Family(net.IPv4zero)) || !deviceA.Forwarding(network.Family(net.IPv6zero)) {
+		t.Fatalf("expected forwarding to be enabled on the exit node")
+	}
+
+	waitFor(t, "default routes through a", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceB, a.Self().PublicKey), []string{"0.0.0.0/0", "::/0", "fd10::1/128"}) && deviceB.DefaultRoute()
+	})
+	if routes := sortedStrings(routesB.Routes()); !slices.Equal(routes, []string{"fd10::1/128"}) {
+		t.Fatalf("default routes must not be installed in the main table: %v", routes)
+	}
+
+	// The exit mark must survive the reconciliation and the key rotations.
+	exitMark := func() int {
+		t.Helper()
+
+		tuning, err := deviceB.Tuning()
+		if err != nil {
+			t.Fatalf("failed to read tuning: %v", err)
+		}
+		return tuning.FirewallMark
+	}
+	drift, err := b.Reconcile(false)
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if drift.Tuning || exitMark() != network.ExitFirewallMark {
+		t.Fatalf("expected the exit mark to be kept, got %#x (drift %+v)", exitMark(), drift)
+	}
+	if _, err := b.RotateKey(context.Background()); err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+	if exitMark() != network.ExitFirewallMark {
+		t.Fatalf("expected the exit mark to be kept after a key rotation, got %#x", exitMark())
+	}
+
+	if err := b.SetExitNode("unknown"); !errors.Is(err, ErrUnknownExitNode) {
+		t.Fatalf("expected ErrUnknownExitNode, got %v", err)
+	}
+
+	configC := newConfig(t, "c", "fd10::/64", 128, "fd10::3")
+	configC.ExitNode = true
+	c, _ := startNode(t, registry, configC)
+	waitForPeers(t, deviceB, 2)
+
+	exit := c.Self().PublicKey
+	if err := b.SetExitNode(exit.String()); err != nil {
+		t.Fatalf("failed to switch exit node: %v", err)
+	}
+
+	if got := peerAllowedIPs(deviceB, a.Self().PublicKey); !slices.Equal(got, []string{"fd10::1/128"}) {
+		t.Fatalf("unexpected allowed ips for the previous exit node: %v", got)
+	}
+	if got := peerAllowedIPs(deviceB, c.Self().PublicKey); !slices.Equal(got, []string{"0.0.0.0/0", "::/0", "fd10::3/128"}) {
+		t.Fatalf("unexpected allowed ips for the new exit node: %v", got)
+	}
+	if !deviceB.DefaultRoute() {
+		t.Fatalf("expected the default route to be kept while switching exit nodes")
+	}
+
+	if err := b.SetExitNode(""); err != nil {
+		t.Fatalf("failed to clear exit node: %v", err)
+	}
+	if deviceB.DefaultRoute() {
+		t.Fatalf("expected the default route to be removed")
+	}
+	if exitMark() != 0 {
+		t.Fatalf("expected the exit mark to be removed, got %#x", exitMark())
+	}
+	if len(deviceB.Peers()) != 2 {
+		t.Fatalf("expected the mesh peers to be kept, got %v", deviceB.Peers())
+	}
+}
+
+func TestPeerRoutes(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	a, _ := startNode(t, registry, newConfig(t, "a", "fd10::/64", 128, "fd10::1"))
+	b, _, routesB := startRoutedNode(t, registry, newConfig(t, "b", "fd10::/64", 128, "fd10::2"))
+	_, _ = startNode(t, registry, newConfig(t, "c", "fd10::/64", 128, "fd10::3"))
+
+	waitFor(t, "routes to a and c on b", func() bool {
+		return slices.Equal(sortedStrings(routesB.Routes()), []string{"fd10::1/128", "fd10::3/128"})
+	})
+
+	if err := a.Leave(context.Background(), false); err != nil {
+		t.Fatalf("failed to leave: %v", err)
+	}
+
+	waitFor(t, "route to a to be removed on b", func() bool {
+		return slices.Equal(sortedStrings(routesB.Routes()), []string{"fd10::3/128"})
+	})
+
+	b.Stop()
+	if routes := routesB.Routes(); len(routes) != 0 {
+		t.Fatalf("expected routes to be cleaned up on stop, got %v", routes)
+	}
+}
+
+func peerPresharedKey(device *network.FakeDevice, publicKey types.PublicKey) wgtypes.Key {
+	for _, peer := range device.Peers() {
+		if peer.PublicKey == publicKey.WG() {
+			return peer.PresharedKey
+		}
+	}
+
+	return wgtypes.Key{}
+}
+
+func TestPresharedKeys(t *testing.T) {
+	s := natstest.StartServer(t)
+	registry := state.NewMemoryRegistry()
+
+	nodes := make([]*Ikto, 0, 3)
+	devices := make([]*network.FakeDevice, 0, 3)
+	for index, name := range []string{"a", "b", "c"} {
+		config := newConfig(t, name, "fd10::/64", 128, fmt.Sprintf("fd10::%d", index+1))
+		config.NatsKV = "ikto-test"
+		config.PresharedKeys = true
+
+		node, device := startNode(t, registry, config, WithNatsConn(natstest.Connect(t, s)))
+		nodes = append(nodes, node)
+		devices = append(devices, device)
+	}
+
+	seen := map[wgtypes.Key]bool{}
+	for i := range nodes {
+		for j := range nodes {
+			if i >= j {
+				continue
+			}
+
+			waitFor(t, fmt.Sprintf("preshared key between %s and %s", nodes[i].Self().Name, nodes[j].Self().Name), func() bool {
+				key := peerPresharedKey(devices[i], nodes[j].Self().PublicKey)
+				return key != (wgtypes.Key{}) && key == peerPresharedKey(devices[j], nodes[i].Self().PublicKey)
+			})
+
+			key := peerPresharedKey(devices[i], nodes[j].Self().PublicKey)
+			if seen[key] {
+				t.Fatalf("expected a distinct preshared key for every pair of peers")
+			}
+			seen[key] = true
+
+			if _, ok := nodes[i].PresharedKeyNegotiated(nodes[j].Self().PublicKey); !ok {
+				t.Fatalf("expected the negotiation to be recorded")
+			}
+		}
+	}
+
+	// The responder applies a new key once the initiator confirms it.
+	initiator, responder := 0, 1
+	first, second := nodes[0].Self().PublicKey, nodes[1].Self().PublicKey
+	if bytes.Compare(first[:], second[:]) > 0 {
+		initiator, responder = 1, 0
+	}
+	previous := peerPresharedKey(devices[responder], nodes[initiator].Self().PublicKey)
+	nc := natstest.Connect(t, s)
+
+	exchange, request, err := psk.Initiate(nodes[initiator].privateKey, nodes[responder].Self().PublicKey.WG(), time.Now())
+	if err != nil {
+		t.Fatalf("failed to initiate: %v", err)
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("failed to encode request: %v", err)
+	}
+	subject := nodes[responder].presharedKeys.subject(nodes[responder].Self().PublicKey.WG())
+	msg, err := nc.Request(subject, data, time.Second)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+
+	var response psk.Response
+	if err := json.Unmarshal(msg.Data, &response); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	key, err := exchange.Finish(response)
+	if err != nil {
+		t.Fatalf("failed to finish: %v", err)
+	}
+	if peerPresharedKey(devices[responder], nodes[initiator].Self().PublicKey) != previous {
+		t.Fatalf("expected the responder to keep the previous key until the confirmation")
+	}
+
+	data, err = json.Marshal(exchange.Confirm(key))
+	if err != nil {
+		t.Fatalf("failed to encode confirmation: %v", err)
+	}
+	if _, err := nc.Request(subject+".confirm", data, time.Second); err != nil {
+		t.Fatalf("failed to confirm: %v", err)
+	}
+	if peerPresharedKey(devices[responder], nodes[initiator].Self().PublicKey) != key {
+		t.Fatalf("expected the responder to apply the confirmed key")
+	}
+}
+
+func TestPresharedKeyBackoff(t *testing.T) {
+	p := newPresharedKeys(nil, "ikto-test", wgtypes.Key{}, nil, 0, nil)
+	publicKey := wgtypes.Key{1}
+	now := time.Now()
+
+	delays := []time.Duration{}
+	for range 11 {
+		delays = append(delays, p.backoff(publicKey, now, false))
+	}
+	if delays[0] != presharedKeyRetryDelay || delays[1] != 2*presharedKeyRetryDelay {
+		t.Fatalf("expected the delay to double from %s, got %v", presharedKeyRetryDelay, delays)
+	}
+	if delays[len(delays)-1] != presharedKeyMaxRetryDelay {
+		t.Fatalf("expected the delay to be capped at %s, got %v", presharedKeyMaxRetryDelay, delays)
+	}
+	if at := p.retryAt(publicKey); !at.Equal(now.Add(presharedKeyMaxRetryDelay)) {
+		t.Fatalf("unexpected retry time %s", at)
+	}
+
+	p.renegotiate(publicKey)
+	if at := p.retryAt(publicKey); !at.IsZero() {
+		t.Fatalf("expected the backoff to be reset, got a retry at %s", at)
+	}
+
+	// An unconfirmed key may be applied by the responder only.
+	for range 11 {
+		if delay := p.backoff(publicKey, now, true); delay > presharedKeyMaxConfirmDelay {
+			t.Fatalf("expected the delay to be capped at %s, got %s", presharedKeyMaxConfirmDelay, delay)
+		}
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+	store := state.NewStore(registry)
+
+	configA := withSecondary(t, newConfig(t, "a", "fd10::/64", 128, "fd10::1"), "10.0.0.0/16", 32)
+	configA.SecondaryPrivateAddress = net.ParseIP("10.0.0.1")
+	a, deviceA := startNode(t, registry, configA)
+	_, deviceB := startNode(t, registry, newConfig(t, "b", "fd10::/64", 128, "fd10::2"))
+
+	waitForPeers(t, deviceB, 1)
+	previous := a.Self().PublicKey
+
+	publicKey, err := a.RotateKey(context.Background())
+	if err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+	if publicKey == previous || a.Self().PublicKey != publicKey {
+		t.Fatalf("expected a new public key, got %s", publicKey.String())
+	}
+
+	waitFor(t, "b to swap a's key", func() bool {
+		peers := deviceB.Peers()
+		return len(peers) == 1 && peers[0].PublicKey == publicKey.WG()
+	})
+	if !slices.Contains(deviceB.Calls(), fmt.Sprintf("SwapPeer %s %s", previous.String(), publicKey.String())) {
+		t.Fatalf("expected b to swap the peer in place, got calls %v", deviceB.Calls())
+	}
+
+	device, err := deviceA.Device()
+	if err != nil {
+		t.Fatalf("failed to read device: %v", err)
+	}
+	if device.PublicKey != publicKey.WG() {
+		t.Fatalf("expected the device to use the new key, got %s", device.PublicKey)
+	}
+	if len(deviceA.Peers()) != 1 {
+		t.Fatalf("expected a not to peer with itself, got %v", deviceA.Peers())
+	}
+
+	data, err := os.ReadFile(configA.PrivateKeyPath)
+	if err != nil {
+		t.Fatalf("failed to read private key: %v", err)
+	}
+	key, err := wgtypes.ParseKey(strings.TrimSpace(string(data)))
+	if err != nil || key.PublicKey() != publicKey.WG() {
+		t.Fatalf("expected the new key to be persisted, got %q", data)
+	}
+
+	if _, err := store.ClaimAddress(context.Background(), "10.0.0.1/32", publicKey); err != nil {
+		t.Fatalf("expected the secondary address to be held by the new key: %v", err)
+	}
+}
+
+func withAuthority(t *testing.T, config *Config, authorityKey ed25519.PrivateKey) *Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	config.AuthorityPublicKey = authorityKey.Public().(ed25519.PublicKey)
+	config.NodeKeyPath = filepath.Join(dir, "node.key")
+	config.CertificatePath = filepath.Join(dir, "node.cert")
+
+	nodeKey, err := LoadOrGenerateSigningKey(config.NodeKeyPath)
+	if err != nil {
+		t.Fatalf("failed to generate node key: %v", err)
+	}
+
+	privateKey, err := LoadOrGeneratePrivateKey(config.PrivateKeyPath)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+
+	certificate := authority.Sign(authorityKey, config.Name, nodeKey.Public().(ed25519.PublicKey), types.PublicKey(privateKey.PublicKey()), []net.IPNet{config.MeshIPNet}, time.Now().Add(time.Hour))
+	data, err := json.Marshal(certificate)
+	if err != nil {
+		t.Fatalf("failed to encode certificate: %v", err)
+	}
+	if err := os.WriteFile(config.CertificatePath, data, 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+
+	return config
+}
+
+func TestSignedPeerRecords(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	authorityKey, err := authority.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate authority key: %v", err)
+	}
+
+	a, deviceA := startNode(t, registry, withAuthority(t, newConfig(t, "a", "fd10::/64", 128, "fd10::1"), authorityKey))
+	b, _ := startNode(t, registry, withAuthority(t, newConfig(t, "b", "fd10::/64", 128, "fd10::2"), authorityKey))
+
+	intruder := newSelf(t, "fd10::3/128")
+	if _, err := state.NewStore(registry).CreatePeer(context.Background(), intruder); err != nil {
+		t.Fatalf("failed to create intruder: %v", err)
+	}
+
+	waitFor(t, "the intruder to be rejected", func() bool {
+		rejected := a.RejectedPeers()
+		return len(rejected) == 1 && rejected[0].Peer.PublicKey == intruder.PublicKey
+	})
+
+	peers := deviceA.Peers()
+	if len(peers) != 1 || peers[0].PublicKey != b.Self().PublicKey.WG() {
+		t.Fatalf("expected a to peer with b only, got %v", peers)
+	}
+
+	// The certificates bind the wireguard keys and the addresses.
+	if _, err := b.RotateKey(context.Background()); !errors.Is(err, ErrCertifiedKey) {
+		t.Fatalf("expected ErrCertifiedKey, got %v", err)
+	}
+
+	impostor := withAuthority(t, newConfig(t, "d", "fd10::/64", 128, "fd10::5"), authorityKey)
+	impostor.PrivateKeyPath = b.config.PrivateKeyPath
+	_, err = NewIkto(impostor, WithDevice(network.NewFakeDevice(impostor.WGDevName, impostor.WGPort, wgtypes.Key{})), WithRegistry(registry))
+	if !errors.Is(err, authority.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate, got %v", err)
+	}
+
+	outside := withAuthority(t, newConfig(t, "e", "fd10::/64", 128, "fd10::6"), authorityKey)
+	outside.PrivateAddress = net.ParseIP("fd11::6")
+	_, err = NewIkto(outside, WithDevice(network.NewFakeDevice(outside.WGDevName, outside.WGPort, wgtypes.Key{})), WithRegistry(registry))
+	if !errors.Is(err, authority.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate, got %v", err)
+	}
+
+	// A replayed record of b is rejected.
+	entry, err := registry.Get(context.Background(), state.PeerKey(b.Self().AllowedIP))
+	if err != nil {
+		t.Fatalf("failed to get the record of b: %v", err)
+	}
+	if _, err := b.SetLabels(context.Background(), map[string]string{"role": "replayed"}, nil); err != nil {
+		t.Fatalf("failed to set labels: %v", err)
+	}
+	waitFor(t, "the labels of b", func() bool {
+		return peerLabels(a, b.Self().PublicKey)["role"] == "replayed"
+	})
+	latest, err := registry.Get(context.Background(), state.PeerKey(b.Self().AllowedIP))
+	if err != nil {
+		t.Fatalf("failed to get the record of b: %v", err)
+	}
+	if _, err := registry.Put(context.Background(), state.PeerKey(b.Self().AllowedIP), entry.Value); err != nil {
+		t.Fatalf("failed to replay the record of b: %v", err)
+	}
+	waitFor(t, "the replayed record to be rejected", func() bool {
+		return slices.ContainsFunc(a.RejectedPeers(), func(rejected state.RejectedPeer) bool {
+			return rejected.Peer.Name == "b" && strings.Contains(rejected.Reason, authority.ErrReplayed.Error())
+		})
+	})
+	if _, err := registry.Put(context.Background(), state.PeerKey(b.Self().AllowedIP), latest.Value); err != nil {
+		t.Fatalf("failed to restore the record of b: %v", err)
+	}
+	waitFor(t, "the latest record of b", func() bool {
+		return peerLabels(a, b.Self().PublicKey)["role"] == "replayed" && len(deviceA.Peers()) == 1
+	})
+
+	// Only the policies signed by the authority are applied.
+	isolate := types.Policy{Name: "isolate", Peers: "role=none"}
+	if err := a.PutPolicy(context.Background(), isolate); !errors.Is(err, authority.ErrUnsigned) {
+		t.Fatalf("expected ErrUnsigned, got %v", err)
+	}
+	if err := state.NewStore(registry).PutPolicy(context.Background(), isolate); err != nil {
+		t.Fatalf("failed to put unsigned policy: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if len(a.state.Policies()) != 0 || len(deviceA.Peers()) != 1 {
+		t.Fatalf("expected the unsigned policy to be ignored")
+	}
+
+	signed, err := authority.SignPolicy(authorityKey, isolate)
+	if err != nil {
+		t.Fatalf("failed to sign policy: %v", err)
+	}
+	if err := a.PutPolicy(context.Background(), signed); err != nil {
+		t.Fatalf("failed to put signed policy: %v", err)
+	}
+	waitForPeers(t, deviceA, 0)
+
+	otherKey, err := authority.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate authority key: %v", err)
+	}
+	configC := withAuthority(t, newConfig(t, "c", "fd10::/64", 128, "fd10::4"), otherKey)
+	configC.AuthorityPublicKey = authorityKey.Public().(ed25519.PublicKey)
+
+	device := network.NewFakeDevice(configC.WGDevName, configC.WGPort, wgtypes.Key{})
+	_, err = NewIkto(configC, WithDevice(device), WithRegistry(registry))
+	if !errors.Is(err, authority.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate, got %v", err)
+	}
+}
+
+func TestAllocateCertifiedAddress(t *testing.T) {
+	authorityKey, err := authority.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate authority key: %v", err)
+	}
+
+	// The certificate only covers the upper half of the mesh.
+	_, mesh, _ := net.ParseCIDR("fd10::/120")
+	_, certified, _ := net.ParseCIDR("fd10::80/121")
+	config := newConfig(t, "auto", "fd10::/120", 124, "")
+	config.PrivateAddress = nil
+	config.MeshIPNet = *certified
+	withAuthority(t, config, authorityKey)
+	config.MeshIPNet = *mesh
+
+	node, _ := startNode(t, state.NewMemoryRegistry(), config)
+	if _, subnet, err := net.ParseCIDR(node.Self().AllowedIP); err != nil || !certified.Contains(subnet.IP) {
+		t.Fatalf("expected an address within %s, got %s", certified, node.Self().AllowedIP)
+	}
+}
+
+func TestAdmission(t *testing.T) {
+	ctx := context.Background()
+	registry := state.NewMemoryRegistry()
+
+	approvers := []string{"a"}
+
+	// The first node waits like any other unless it bootstraps the mesh.
+	configA := newConfig(t, "a", "fd10::/16", 48, "fd10:a::")
+	configA.RequireApproval = true
+	configA.Approvers = approvers
+	configA.AdmissionTimeout = 100 * time.Millisecond
+	first, err := NewIkto(configA, WithDevice(network.NewFakeDevice(configA.WGDevName, configA.WGPort, wgtypes.Key{})), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(first.Stop)
+	if err := first.Start(); !errors.Is(err, ErrAdmissionTimeout) {
+		t.Fatalf("expected ErrAdmissionTimeout, got %v", err)
+	}
+
+	configA.AdmissionTimeout = 0
+	configA.Bootstrap = true
+	configA.AutoApprove = AutoApproveRules{Names: []string{"auto-*"}}
+	a, deviceA := startNode(t, registry, configA)
+
+	if err := a.approve(ctx, a.Self()); !errors.Is(err, ErrSelfApproval) {
+		t.Fatalf("expected ErrSelfApproval, got %v", err)
+	}
+
+	configB := newConfig(t, "b", "fd10::/16", 48, "fd10:b::")
+	configB.RequireApproval = true
+	configB.Approvers = approvers
+	configB.HeartbeatInterval = 10 * time.Millisecond
+
+	deviceB := network.NewFakeDevice(configB.WGDevName, configB.WGPort, wgtypes.Key{})
+	b, err := NewIkto(configB, WithDevice(deviceB), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(b.Stop)
+
+	started := make(chan error, 1)
+	go func() {
+		started <- b.Start()
+	}()
+
+	waitFor(t, "the admission request of b", func() bool {
+		pending, err := a.PendingPeers(ctx)
+		return err == nil && len(pending) == 1 && pending[0].Peer.Name == "b"
+	})
+
+	select {
+	case err := <-started:
+		t.Fatalf("expected b to wait for its approval, started with %v", err)
+	default:
+	}
+	if len(deviceA.Peers()) != 0 {
+		t.Fatal("expected a not to peer with b before its approval")
+	}
+
+	if _, err := a.ApprovePeer(ctx, "c"); !errors.Is(err, ErrNoAdmissionRequest) {
+		t.Fatalf("expected ErrNoAdmissionRequest, got %v", err)
+	}
+
+	approved, err := a.ApprovePeer(ctx, "b")
+	if err != nil {
+		t.Fatalf("failed to approve b: %v", err)
+	}
+	if approved.PublicKey != b.Self().PublicKey {
+		t.Fatalf("expected b to be approved, got %s", approved.PublicKey.String())
+	}
+
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatalf("failed to start b: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for b to start")
+	}
+
+	waitForPeers(t, deviceA, 1)
+	waitForPeers(t, deviceB, 1)
+
+	if err := b.approve(ctx, types.Peer{Name: "x", PublicKey: types.PublicKey{1}}); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected ErrNotApprover, got %v", err)
+	}
+
+	// The nodes approve their rotated keys themselves.
+	rotatedA, err := a.RotateKey(ctx)
+	if err != nil {
+		t.Fatalf("failed to rotate the key of a: %v", err)
+	}
+	rotatedB, err := b.RotateKey(ctx)
+	if err != nil {
+		t.Fatalf("failed to rotate the key of b: %v", err)
+	}
+	waitFor(t, "the rotated keys", func() bool {
+		_, okA := devicePeer(deviceB, rotatedA)
+		_, okB := devicePeer(deviceA, rotatedB)
+		return okA && okB && len(a.RejectedPeers()) == 0 && len(b.RejectedPeers()) == 0
+	})
+
+	pending, err := a.PendingPeers(ctx)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected no admission request left, got %v (%v)", pending, err)
+	}
+
+	// Nodes matching the auto-approval rules of a join on their own.
+	configC := newConfig(t, "auto-c", "fd10::/16", 48, "fd10:c::")
+	configC.RequireApproval = true
+	configC.Approvers = approvers
+	configC.HeartbeatInterval = 10 * time.Millisecond
+	_, deviceC := startNode(t, registry, configC)
+
+	waitForPeers(t, deviceA, 2)
+	waitForPeers(t, deviceC, 2)
+
+	// A request left unapproved is withdrawn after the admission timeout.
+	configD := newConfig(t, "d", "fd10::/16", 48, "fd10:d::")
+	configD.RequireApproval = true
+	configD.Approvers = approvers
+	configD.HeartbeatInterval = 10 * time.Millisecond
+	configD.AdmissionTimeout = 100 * time.Millisecond
+
+	d, err := NewIkto(configD, WithDevice(network.NewFakeDevice(configD.WGDevName, configD.WGPort, wgtypes.Key{})), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(d.Stop)
+
+	if err := d.Start(); !errors.Is(err, ErrAdmissionTimeout) {
+		t.Fatalf("expected ErrAdmissionTimeout, got %v", err)
+	}
+
+	pending, err = a.PendingPeers(ctx)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected the admission request of d to be withdrawn, got %v (%v)", pending, err)
+	}
+
+	// The mesh is bootstrapped once.
+	configE := newConfig(t, "e", "fd10::/16", 48, "fd10:e::")
+	configE.RequireApproval = true
+	configE.Approvers = approvers
+	configE.Bootstrap = true
+
+	e, err := NewIkto(configE, WithDevice(network.NewFakeDevice(configE.WGDevName, configE.WGPort, wgtypes.Key{})), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(e.Stop)
+
+	if err := e.Start(); !errors.Is(err, ErrBootstrapped) {
+		t.Fatalf("expected ErrBootstrapped, got %v", err)
+	}
+
+	// A node approving itself is rejected.
+	configF := newConfig(t, "f", "fd10::/16", 48, "fd10:f::")
+	configF.RequireApproval = true
+	configF.Approvers = approvers
+
+	f, err := NewIkto(configF, WithDevice(network.NewFakeDevice(configF.WGDevName, configF.WGPort, wgtypes.Key{})), WithRouteManager(network.NewFakeRouteManager()), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(f.Stop)
+
+	err = state.NewStore(registry).PutApproval(ctx, types.Approval{PublicKey: f.Self().PublicKey, Name: "f", Approver: "f", Time: time.Now()})
+	if err != nil {
+		t.Fatalf("failed to write approval: %v", err)
+	}
+	if err := f.Start(); err != nil {
+		t.Fatalf("failed to start f: %v", err)
+	}
+
+	waitFor(t, "a to reject f", func() bool {
+		return slices.ContainsFunc(a.RejectedPeers(), func(rejected state.RejectedPeer) bool {
+			return rejected.Peer.Name == "f" && rejected.Reason == state.ErrNotApproved.Error()
+		})
+	})
+	if len(deviceA.Peers()) != 2 {
+		t.Fatalf("expected a to peer with b and auto-c only, got %d peers", len(deviceA.Peers()))
+	}
+}
+
+func peerLabels(node *Ikto, publicKey types.PublicKey) map[string]string {
+	for _, peer := range node.Peers() {
+		if peer.PublicKey == publicKey {
+			return peer.Labels
+		}
+	}
+	return nil
+}
+
+func TestLabels(t *testing.T) {
+	ctx := context.Background()
+	registry := state.NewMemoryRegistry()
+
+	configA := newConfig(t, "a", "fd10::/16", 48, "fd10:a::")
+	configA.Labels = map[string]string{"role": "hub"}
+	configA.Annotations = map[string]string{"owner": "team network"}
+	a, _ := startNode(t, registry, configA)
+	b, _ := newNode(t, registry, "b", "fd10:b::")
+
+	waitFor(t, "b to see the labels of a", func() bool {
+		return peerLabels(b, a.Self().PublicKey)["role"] == "hub"
+	})
+
+	self, err := a.SetLabels(ctx, map[string]string{"region": "eu-west"}, []string{"role"})
+	if err != nil {
+		t.Fatalf("failed to set labels: %v", err)
+	}
+	if len(self.Labels) != 1 || self.Labels["region"] != "eu-west" {
+		t.Fatalf("unexpected labels: %v", self.Labels)
+	}
+	if configA.Labels["region"] != "" {
+		t.Fatal("expected the labels of the configuration to be left untouched")
+	}
+
+	waitFor(t, "b to see the new labels of a", func() bool {
+		labels := peerLabels(b, a.Self().PublicKey)
+		return len(labels) == 1 && labels["region"] == "eu-west"
+	})
+
+	if _, err := a.SetLabels(ctx, map[string]string{"role": "a hub"}, nil); err == nil {
+		t.Fatal("expected an invalid label value to be refused")
+	}
+
+	if _, err := a.SetAnnotations(ctx, nil, []string{"owner"}); err != nil {
+		t.Fatalf("failed to remove annotation: %v", err)
+	}
+	if a.Self().Annotations != nil {
+		t.Fatalf("expected no annotations left, got %v", a.Self().Annotations)
+	}
+}
+
+func TestTopologyPolicies(t *testing.T) {
+	ctx := context.Background()
+	registry := state.NewMemoryRegistry()
+
+	startLabelled := func(name string, address string, labels map[string]string) (*Ikto, *network.FakeDevice) {
+		config := newConfig(t, name, "fd10::/16", 48, address)
+		config.Labels = labels
+		return startNode(t, registry, config)
+	}
+
+	hub, deviceHub := startLabelled("hub", "fd10:a::", map[string]string{"role": "hub", "region": "eu"})
+	edgeEU, deviceEdgeEU := startLabelled("edge-eu", "fd10:b::", map[string]string{"role": "edge", "region": "eu"})
+	edgeUS, deviceEdgeUS := startLabelled("edge-us", "fd10:c::", map[string]string{"role": "edge", "region": "us"})
+
+	waitForPeers(t, deviceHub, 2)
+	waitForPeers(t, deviceEdgeEU, 2)
+	waitForPeers(t, deviceEdgeUS, 2)
+
+	err := hub.PutPolicy(ctx, types.Policy{
+		Name:       "edges",
+		Nodes:      "role=edge",
+		Peers:      "role=hub",
+		SameLabels: []string{"region"},
+	})
+	if err != nil {
+		t.Fatalf("failed to put policy: %v", err)
+	}
+
+	waitForPeers(t, deviceHub, 1)
+	waitForPeers(t, deviceEdgeEU, 1)
+	waitForPeers(t, deviceEdgeUS, 0)
+
+	if got := deviceHub.Peers()[0].PublicKey; got != edgeEU.Self().PublicKey.WG() {
+		t.Fatalf("expected the hub to peer with edge-eu, got %s", got)
+	}
+	if len(edgeUS.Peers()) != 0 || len(edgeUS.PeerStatuses()) != 2 {
+		t.Fatal("expected edge-us to know its excluded peers without selecting them")
+	}
+
+	// Moving edge-us to the region of the hub makes them peer.
+	_, err = edgeUS.SetLabels(ctx, map[string]string{"region": "eu"}, nil)
+	if err != nil {
+		t.Fatalf("failed to set labels: %v", err)
+	}
+
+	waitForPeers(t, deviceHub, 2)
+	waitForPeers(t, deviceEdgeUS, 1)
+	waitForPeers(t, deviceEdgeEU, 1)
+
+	if err := edgeEU.DeletePolicy(ctx, "edges"); err != nil {
+		t.Fatalf("failed to delete policy: %v", err)
+	}
+	if err := edgeEU.DeletePolicy(ctx, "edges"); !errors.Is(err, ErrUnknownPolicy) {
+		t.Fatalf("expected ErrUnknownPolicy, got %v", err)
+	}
+
+	waitForPeers(t, deviceEdgeEU, 2)
+	waitForPeers(t, deviceEdgeUS, 2)
+}
+
+type meshNode struct {
+	node   *Ikto
+	device *network.FakeDevice
+}
+
+// send reports whether a packet from src reaches the node at dst, each hop
+// going to the peer whose allowed IPs contain dst, and being dropped unless
+// the allowed IPs of the sender on the receiving node contain the source,
+// as WireGuard does. The direct paths between the nodes of broken drop
+// every packet.
+func send(nodes []meshNode, broken [2]*Ikto, src meshNode, dst net.IP) bool {
+	source := net.ParseIP(strings.TrimSuffix(src.node.Self().AllowedIP, "/128"))
+	contains := func(ipnets []net.IPNet, ip net.IP) bool {
+		return slices.ContainsFunc(ipnets, func(ipnet net.IPNet) bool {
+			return ipnet.Contains(ip)
+		})
+	}
+
+	current := src
+	for range nodes {
+		index := -1
+		for _, peer := range current.device.Peers() {
+			if contains(peer.AllowedIPs, dst) {
+				index = slices.IndexFunc(nodes, func(node meshNode) bool {
+					return node.node.Self().PublicKey.WG() == peer.PublicKey
+				})
+			}
+		}
+		if index < 0 {
+			return false
+		}
+		next := nodes[index]
+		if (current.node == broken[0] && next.node == broken[1]) || (current.node == broken[1] && next.node == broken[0]) {
+			return false
+		}
+
+		sender, ok := devicePeer(next.device, current.node.Self().PublicKey)
+		if !ok || !contains(sender.AllowedIPs, source) {
+			return false
+		}
+		if next.node.Self().AllowedIP == dst.String()+"/128" {
+			return true
+		}
+		current = next
+	}
+
+	return false
+}
+
+func TestRelay(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	configA := newConfig(t, "a", "fd10::/64", 128, "fd10::1")
+	configA.RelayTimeout = time.Second
+	a, deviceA := startNode(t, registry, configA)
+	configB := newConfig(t, "b", "fd10::/64", 128, "fd10::2")
+	configB.RelayTimeout = time.Second
+	b, deviceB := startNode(t, registry, configB)
+
+	configR := newConfig(t, "r", "fd10::/64", 128, "fd10::3")
+	configR.Relay = true
+	r, deviceR := startNode(t, registry, configR)
+
+	if !deviceR.Forwarding(network.Family(net.IPv4zero)) || !deviceR.Forwarding(network.Family(net.IPv6zero)) {
+		t.Fatalf("expected forwarding to be enabled on the relay")
+	}
+	waitForPeers(t, deviceA, 2)
+
+	// a keeps sending to b without ever completing a handshake.
+	var handshake time.Time
+	var mutex sync.Mutex
+	go func() {
+		for transmitted := int64(1); t.Context().Err() == nil; transmitted++ {
+			mutex.Lock()
+			deviceA.SetPeerStats(b.Self().PublicKey.WG(), handshake, transmitted)
+			mutex.Unlock()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	waitFor(t, "b relayed through r", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceA, r.Self().PublicKey), []string{"fd10::2/128", "fd10::3/128"}) &&
+			len(peerAllowedIPs(deviceA, b.Self().PublicKey)) == 0
+	})
+
+	// b only receives, yet relays a too so that the traffic flows both ways.
+	nodes := []meshNode{{a, deviceA}, {b, deviceB}, {r, deviceR}}
+	broken := [2]*Ikto{a, b}
+	waitFor(t, "traffic between a and b through r", func() bool {
+		return send(nodes, broken, nodes[0], net.ParseIP("fd10::2")) && send(nodes, broken, nodes[1], net.ParseIP("fd10::1"))
+	})
+	if path, ok := b.RelayedPeers()[a.Self().PublicKey]; !ok || path.Relay != r.Self().PublicKey || !path.Requested {
+		t.Fatalf("unexpected relayed peers of b: %v", b.RelayedPeers())
+	}
+
+	path, ok := a.RelayedPeers()[b.Self().PublicKey]
+	if !ok || path.Relay != r.Self().PublicKey {
+		t.Fatalf("unexpected relayed peers: %v", a.RelayedPeers())
+	}
+	for _, peer := range deviceA.Peers() {
+		if peer.PublicKey == b.Self().PublicKey.WG() && peer.PersistentKeepaliveInterval != types.RelayProbeInterval {
+			t.Fatalf("expected the direct path to b to keep being probed, got keepalive %s", peer.PersistentKeepaliveInterval)
+		}
+	}
+
+	// A handshake completing over the direct path restores it.
+	mutex.Lock()
+	handshake = time.Now()
+	mutex.Unlock()
+
+	waitFor(t, "direct path to b", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceA, r.Self().PublicKey), []string{"fd10::3/128"}) &&
+			slices.Equal(peerAllowedIPs(deviceA, b.Self().PublicKey), []string{"fd10::2/128"})
+	})
+	if len(a.RelayedPeers()) != 0 {
+		t.Fatalf("expected no relayed peer, got %v", a.RelayedPeers())
+	}
+	waitFor(t, "direct path to a", func() bool {
+		return slices.Equal(peerAllowedIPs(deviceB, r.Self().PublicKey), []string{"fd10::3/128"}) &&
+			slices.Equal(peerAllowedIPs(deviceB, a.Self().PublicKey), []string{"fd10::1/128"})
+	})
+}
+
+func devicePeer(device *network.FakeDevice, publicKey types.PublicKey) (wgtypes.Peer, bool) {
+	for _, peer := range device.Peers() {
+		if peer.PublicKey == publicKey.WG() {
+			return peer, true
+		}
+	}
+
+	return wgtypes.Peer{}, false
+}
+
+func TestObservedEndpoints(t *testing.T) {
+	s := natstest.StartServer(t)
+	registry := state.NewMemoryRegistry()
+
+	startNATed := func(name string, address string) (*Ikto, *network.FakeDevice) {
+		config := newConfig(t, name, "fd10::/64", 128, address)
+		config.AdvertiseAddress = nil
+		config.NatsKV = "ikto-test"
+		config.ObservedEndpoints = true
+		return startNode(t, registry, config, WithNatsConn(natstest.Connect(t, s)))
+	}
+
+	a, deviceA := startNATed("a", "fd10::1")
+	b, deviceB := startNATed("b", "fd10::2")
+	configC := newConfig(t, "c", "fd10::/64", 128, "fd10::3")
+	configC.ObservedEndpoints = true
+	c, deviceC := startNode(t, registry, configC)
+
+	waitForPeers(t, deviceA, 2)
+	waitForPeers(t, deviceB, 2)
+	waitForPeers(t, deviceC, 2)
+
+	if peer, _ := devicePeer(deviceA, c.Self().PublicKey); peer.PersistentKeepaliveInterval != types.NATKeepaliveInterval {
+		t.Fatalf("expected a node behind a NAT to keep its mappings open, got keepalive %s", peer.PersistentKeepaliveInterval)
+	}
+	if peer, _ := devicePeer(deviceC, a.Self().PublicKey); peer.Endpoint != nil {
+		t.Fatalf("expected no endpoint for a peer without advertise address, got %s", peer.Endpoint)
+	}
+
+	// c completes handshakes with a and b from the addresses of their NATs.
+	endpointA := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 40000}
+	endpointB := &net.UDPAddr{IP: net.ParseIP("203.0.113.2"), Port: 50000}
+	deviceC.SetPeerEndpoint(a.Self().PublicKey.WG(), endpointA)
+	deviceC.SetPeerStats(a.Self().PublicKey.WG(), time.Now(), 1)
+	deviceC.SetPeerEndpoint(b.Self().PublicKey.WG(), endpointB)
+	deviceC.SetPeerStats(b.Self().PublicKey.WG(), time.Now(), 1)
+
+	waitFor(t, "observed endpoints applied", func() bool {
+		peerB, _ := devicePeer(deviceA, b.Self().PublicKey)
+		peerA, _ := devicePeer(deviceB, a.Self().PublicKey)
+		return peerB.Endpoint.String() == endpointB.String() && peerA.Endpoint.String() == endpointA.String()
+	})
+
+	// Both ends send to each other at the same time.
+	keyA, keyB := a.Self().PublicKey, b.Self().PublicKey
+	waitFor(t, "hole punching on both ends", func() bool {
+		return slices.Contains(deviceA.Calls(), "Probe "+keyB.String()) &&
+			slices.Contains(deviceB.Calls(), "Probe "+keyA.String())
+	})
+
+	endpoints, err := a.PeerEndpoints()
+	if err != nil {
+		t.Fatalf("failed to read peer endpoints: %v", err)
+	}
+	if endpoints[b.Self().PublicKey] != endpointB.String() {
+		t.Fatalf("unexpected endpoints: %v", endpoints)
+	}
+
+	// The observations published under the address of a peer by another
+	// node are ignored.
+	err = state.NewStore(registry).PutObservedEndpoints(context.Background(), c.Self().AllowedIP, types.ObservedEndpoints{
+		Name:      "c",
+		PublicKey: newSelf(t, c.Self().AllowedIP).PublicKey,
+		Endpoints: map[string]string{keyB.String(): "198.51.100.1:1"},
+		Time:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("failed to publish forged endpoints: %v", err)
+	}
+	waitFor(t, "forged endpoints ignored", func() bool {
+		return !slices.ContainsFunc(a.state.ObservedEndpoints(), func(observed types.ObservedEndpoints) bool {
+			return observed.Name == "c"
+		})
+	})
+}
+
+func TestEndpointFailover(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+
+	configA := newConfig(t, "a", "fd10::/64", 128, "fd10::1")
+	configA.EndpointTimeout = 2 * time.Second
+	a, deviceA := startNode(t, registry, configA)
+
+	configB := newConfig(t, "b", "fd10::/64", 128, "fd10::2")
+	configB.Endpoints = []string{"192.0.2.10:51820", "[2001:db8::10]:51820"}
+	b, _ := startNode(t, registry, configB)
+
+	waitForPeers(t, deviceA, 1)
+	keyB := b.Self().PublicKey
+	if peer, _ := devicePeer(deviceA, keyB); peer.Endpoint.String() != "192.0.2.10:51820" {
+		t.Fatalf("expected the first candidate endpoint, got %s", peer.Endpoint)
+	}
+
+	// a keeps sending to b without completing a handshake.
+	var handshake time.Time
+	var mutex sync.Mutex
+	go func() {
+		for transmitted := int64(1); t.Context().Err() == nil; transmitted++ {
+			mutex.Lock()
+			deviceA.SetPeerStats(keyB.WG(), handshake, transmitted)
+			mutex.Unlock()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	waitFor(t, "failover to the second candidate", func() bool {
+		peer, _ := devicePeer(deviceA, keyB)
+		return peer.Endpoint.String() == "[2001:db8::10]:51820"
+	})
+	mutex.Lock()
+	handshake = time.Now()
+	mutex.Unlock()
+
+	failovers := a.EndpointFailovers()[keyB]
+	if len(failovers) != 1 || failovers[0].From != "192.0.2.10:51820" || failovers[0].To != "[2001:db8::10]:51820" {
+		t.Fatalf("unexpected failovers: %+v", failovers)
+	}
+}
+
+func TestTuning(t *testing.T) {
+	registry := state.NewMemoryRegistry()
+	defaults := types.Tuning{PersistentKeepalive: 15, MTU: 1380}
+
+	configA := newConfig(t, "a", "fd10::/64", 128, "fd10::1")
+	configA.TuningDefaults = defaults
+	configB := newConfig(t, "b", "fd10::/64", 128, "fd10::2")
+	configB.TuningDefaults = defaults
+	configB.Tuning = types.Tuning{PersistentKeepalive: 5, FirewallMark: 0x51820, TxQueueLen: 500}
+
+	a, deviceA := startNode(t, registry, configA)
+	b, deviceB := startNode(t, registry, configB)
+
+	waitForPeers(t, deviceA, 1)
+	waitForPeers(t, deviceB, 1)
+
+	// Each node keeps the other alive at the keepalive it publishes.
+	waitFor(t, "keepalive of b overridden", func() bool {
+		peer, _ := devicePeer(deviceA, b.Self().PublicKey)
+		return peer.PersistentKeepaliveInterval == 5*time.Second
+	})
+	if peer, _ := devicePeer(deviceB, a.Self().PublicKey); peer.PersistentKeepaliveInterval != 15*time.Second {
+		t.Fatalf("expected the default keepalive for a, got %s", peer.PersistentKeepaliveInterval)
+	}
+
+	tuningA, err := deviceA.Tuning()
+	if err != nil {
+		t.Fatalf("failed to read the tuning of a: %v", err)
+	}
+	if tuningA != (types.Tuning{MTU: 1380, TxQueueLen: 1000}) {
+		t.Fatalf("unexpected tuning of a: %+v", tuningA)
+	}
+	tuningB, err := deviceB.Tuning()
+	if err != nil {
+		t.Fatalf("failed to read the tuning of b: %v", err)
+	}
+	if tuningB != (types.Tuning{MTU: 1380, FirewallMark: 0x51820, TxQueueLen: 500}) {
+		t.Fatalf("unexpected tuning of b: %+v", tuningB)
+	}
+
+	if err := b.SetExitNode("a"); !errors.Is(err, ErrExitFirewallMark) {
+		t.Fatalf("expected ErrExitFirewallMark, got %v", err)
+	}
+
+	deviceB.SetMTU(1500)
+	drift, err := b.Reconcile(false)
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if !drift.Tuning {
+		t.Fatalf("expected the mtu change to be detected, got %+v", drift)
+	}
+	if tuningB, _ := deviceB.Tuning(); tuningB.MTU != 1380 {
+		t.Fatalf("expected the mtu to be restored, got %d", tuningB.MTU)
+	}
+	if b.ReconcileMetrics().TuningFixes != 1 {
+		t.Fatalf("expected one tuning fix, got %+v", b.ReconcileMetrics())
+	}
+}
+
+func TestReaping(t *testing.T) {
+	ctx := context.Background()
+	registry := state.NewMemoryRegistry()
+	store := state.NewStore(registry)
+
+	fast := func(config *Config) *Config {
+		config.HeartbeatInterval = 20 * time.Millisecond
+		config.PeerGracePeriod = 300 * time.Millisecond
+		return config
+	}
+
+	a, _ := startNode(t, registry, fast(newConfig(t, "a", "fd10::/16", 48, "fd10:a::")))
+	b, deviceB := startNode(t, registry, fast(newConfig(t, "b", "fd10::/16", 48, "fd10:b::")))
+	waitForPeers(t, deviceB, 1)
+
+	// A record without heartbeats is reaped once its grace period is over.
+	ghost := newSelf(t, "fd10:c::/48")
+	if _, err := store.CreatePeer(ctx, ghost); err != nil {
+		t.Fatalf("failed to create ghost: %v", err)
+	}
+	waitFor(t, "ghost reaped", func() bool {
+		_, _, err := store.GetPeer(ctx, ghost.AllowedIP)
+		return errors.Is(err, state.ErrKeyNotFound)
+	})
+
+	for _, status := range a.PeerStatuses() {
+		if status.Peer.PublicKey == b.Self().PublicKey && !status.Alive {
+			t.Fatalf("expected b to be alive, last seen %s", status.LastSeen)
+		}
+	}
+
+	// A peer that came back since its status was read is not reaped.
+	back := newSelf(t, "fd10:d::/48")
+	revision, err := store.CreatePeer(ctx, back)
+	if err != nil {
+		t.Fatalf("failed to create peer: %v", err)
+	}
+	if _, err := store.UpdatePeer(ctx, back, revision); err != nil {
+		t.Fatalf("failed to update peer: %v", err)
+	}
+	reaper := &Ikto{store: store}
+	reaper.reapPeer(ctx, state.PeerStatus{Peer: back, Revision: revision})
+	if _, _, err := store.GetPeer(ctx, back.AllowedIP); err != nil {
+		t.Fatalf("expected the peer that came back to be kept, got %v", err)
+	}
+
+	// A live node whose record is deleted registers again.
+	if err := store.DeletePeer(ctx, b.Self().AllowedIP, 0); err != nil {
+		t.Fatalf("failed to delete b: %v", err)
+	}
+	waitFor(t, "b registered again", func() bool {
+		peer, _, err := store.GetPeer(ctx, b.Self().AllowedIP)
+		return err == nil && peer.PublicKey == b.Self().PublicKey
+	})
+
+	leaveCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := b.Leave(leaveCtx, false); err != nil {
+		t.Fatalf("failed to leave after registering again: %v", err)
+	}
+}
+
+func TestReapAddresses(t *testing.T) {
+	ctx := context.Background()
+	store := state.NewStore(state.NewMemoryRegistry())
+
+	peer := newSelf(t, "fd10::1/128")
+	peer.SecondaryIP = "10.0.0.1/32"
+	if _, err := store.ClaimAddress(ctx, peer.SecondaryIP, peer.PublicKey); err != nil {
+		t.Fatalf("failed to claim address: %v", err)
+	}
+	if _, err := store.CreatePeer(ctx, peer); err != nil {
+		t.Fatalf("failed to create peer: %v", err)
+	}
+
+	// The node owning this reservation failed before registering.
+	orphan := newSelf(t, "fd10::2/128")
+	if _, err := store.ClaimAddress(ctx, "10.0.0.2/32", orphan.PublicKey); err != nil {
+		t.Fatalf("failed to claim address: %v", err)
+	}
+
+	reaper := &Ikto{store: store, config: Config{PeerGracePeriod: time.Minute}}
+
+	if err := reaper.reapAddresses(ctx, time.Now()); err != nil {
+		t.Fatalf("failed to reap addresses: %v", err)
+	}
+	if claims, _ := store.ListAddressClaims(ctx); len(claims) != 2 {
+		t.Fatalf("expected recent reservations to be kept, got %v", claims)
+	}
+
+	if err := reaper.reapAddresses(ctx, time.Now().Add(2*time.Minute)); err != nil {
+		t.Fatalf("failed to reap addresses: %v", err)
+	}
+	claims, err := store.ListAddressClaims(ctx)
+	if err != nil {
+		t.Fatalf("failed to list reservations: %v", err)
+	}
+	if len(claims) != 1 || claims[0].IP != peer.SecondaryIP || claims[0].PublicKey != peer.PublicKey {
+		t.Fatalf("expected only the reservation of the registered peer to be kept, got %v", claims)
+	}
+}
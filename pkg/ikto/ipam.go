@@ -0,0 +1,246 @@
+package ikto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/internal/state"
+)
+
+const allocationAttempts = 16
+
+var ErrNoFreeAddress = fmt.Errorf("no free address in mesh network")
+
+func normalizeAddress(mesh net.IPNet, address net.IP) net.IP {
+	if address == nil {
+		return nil
+	}
+
+	if _, bits := mesh.Mask.Size(); bits == 8*net.IPv4len {
+		return address.To4()
+	}
+
+	return address.To16()
+}
+
+func (i *Ikto) setAddress(address net.IP) {
+	address = normalizeAddress(i.config.MeshIPNet, address)
+	i.address = address
+	i.self.AllowedIP = i.config.getPrivateCIDR(address)
+}
+
+func (i *Ikto) setSecondaryAddress(address net.IP) {
+	address = normalizeAddress(*i.config.SecondaryMeshIPNet, address)
+	i.secondaryAddress = address
+	i.self.SecondaryIP = fmt.Sprintf("%s/%d", address.String(), i.config.SecondaryHostPrefixLength)
+}
+
+func (i *Ikto) initPrimary(ctx context.Context) error {
+	if i.config.PrivateAddress != nil {
+		return i.claim()
+	}
+
+	address, err := i.loadAddress(i.config.MeshIPNet)
+	if err == nil {
+		i.setAddress(address)
+		err = i.claim()
+		if !errors.Is(err, ErrAddressAlreadyInUse) {
+			return err
+		}
+		slog.Warn("persisted address is already in use, allocating a new one", "address", address.String())
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	peers, err := i.store.ListPeers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list peers: %w", err)
+	}
+
+	used := make([]net.IPNet, 0, len(peers))
+	for _, peer := range peers {
+		used = appendSubnet(used, peer.AllowedIP)
+	}
+
+	pending, err := i.store.ListPending(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list admission requests: %w", err)
+	}
+	for _, request := range pending {
+		if request.Peer.PublicKey != i.self.PublicKey {
+			used = appendSubnet(used, request.Peer.AllowedIP)
+		}
+	}
+
+	return allocate(i.allocationRange(i.config.MeshIPNet), i.config.HostPrefixLength, used, func(subnet net.IPNet) error {
+		i.setAddress(network.HostAddress(subnet))
+		if err := i.createSelf(ctx); err != nil {
+			return err
+		}
+
+		slog.Info("allocated private address", "allowed_ip", i.self.AllowedIP)
+		return nil
+	})
+}
+
+// initSecondary returns whether a new reservation was created.
+func (i *Ikto) initSecondary(ctx context.Context) (bool, error) {
+	if i.config.SecondaryPrivateAddress != nil {
+		i.setSecondaryAddress(i.config.SecondaryPrivateAddress)
+		return i.claimSecondary(ctx)
+	}
+
+	address, err := i.loadAddress(*i.config.SecondaryMeshIPNet)
+	if err == nil {
+		i.setSecondaryAddress(address)
+		created, err := i.claimSecondary(ctx)
+		if !errors.Is(err, ErrAddressAlreadyInUse) {
+			return created, err
+		}
+		slog.Warn("persisted secondary address is already in use, allocating a new one", "address", address.String())
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	peers, err := i.store.ListPeers(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list peers: %w", err)
+	}
+
+	used := make([]net.IPNet, 0, len(peers))
+	for _, peer := range peers {
+		if peer.PublicKey != i.self.PublicKey {
+			used = appendSubnet(used, peer.SecondaryIP)
+		}
+	}
+
+	err = allocate(i.allocationRange(*i.config.SecondaryMeshIPNet), i.config.SecondaryHostPrefixLength, used, func(subnet net.IPNet) error {
+		i.setSecondaryAddress(network.HostAddress(subnet))
+		_, err := i.claimSecondary(ctx)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("allocated secondary private address", "secondary_ip", i.self.SecondaryIP)
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (i *Ikto) claimSecondary(ctx context.Context) (bool, error) {
+	created, err := i.store.ClaimAddress(ctx, i.self.SecondaryIP, i.self.PublicKey)
+	if errors.Is(err, state.ErrKeyExists) {
+		return false, fmt.Errorf("%w: %s", ErrAddressAlreadyInUse, i.self.SecondaryIP)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim secondary address: %w", err)
+	}
+
+	return created, nil
+}
+
+// allocationRange narrows mesh to a certified prefix within it, if any.
+func (i *Ikto) allocationRange(mesh net.IPNet) net.IPNet {
+	meshOnes, bits := mesh.Mask.Size()
+	for _, prefix := range i.certificate.Prefixes {
+		_, ipnet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			continue
+		}
+
+		ones, prefixBits := ipnet.Mask.Size()
+		if prefixBits == bits && ones >= meshOnes && mesh.Contains(ipnet.IP) {
+			return *ipnet
+		}
+	}
+
+	return mesh
+}
+
+// allocate tries free subnets of mesh until claim succeeds.
+func allocate(mesh net.IPNet, prefix int, used []net.IPNet, claim func(subnet net.IPNet) error) error {
+	for range allocationAttempts {
+		subnet, err := network.FreeSubnet(mesh, prefix, used)
+		if err != nil {
+			if errors.Is(err, network.ErrNoFreeSubnet) {
+				return ErrNoFreeAddress
+			}
+			return fmt.Errorf("failed to find a free subnet: %w", err)
+		}
+
+		err = claim(subnet)
+		if errors.Is(err, ErrAddressAlreadyInUse) {
+			slog.Info("address taken concurrently, retrying", "subnet", subnet.String())
+			used = append(used, subnet)
+			continue
+		}
+
+		return err
+	}
+
+	return ErrNoFreeAddress
+}
+
+func appendSubnet(subnets []net.IPNet, cidr string) []net.IPNet {
+	if cidr == "" {
+		return subnets
+	}
+
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return subnets
+	}
+
+	return append(subnets, *ipnet)
+}
+
+func (i *Ikto) loadAddress(mesh net.IPNet) (net.IP, error) {
+	if i.config.AddressPath == "" {
+		return nil, os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(i.config.AddressPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		address := normalizeAddress(mesh, net.ParseIP(strings.TrimSpace(line)))
+		if address != nil && mesh.Contains(address) {
+			return address, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no address of %s in %s: %w", mesh.String(), i.config.AddressPath, os.ErrNotExist)
+}
+
+func (i *Ikto) saveAddresses() error {
+	if i.config.AddressPath == "" {
+		return nil
+	}
+
+	content := i.address.String() + "\n"
+	if i.secondaryAddress != nil {
+		content += i.secondaryAddress.String() + "\n"
+	}
+
+	err := os.MkdirAll(filepath.Dir(i.config.AddressPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create address directory: %w", err)
+	}
+
+	err = os.WriteFile(i.config.AddressPath, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to save address: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package ikto
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/valyentdev/ikto/internal/authority"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+var ErrInsecureKeyFile = errors.New("key file is readable by group or others")
+
+// LoadPrivateKey refuses a file readable by its group or others.
+func LoadPrivateKey(path string) (wgtypes.Key, error) {
+	data, err := readKeyFile(path)
+	if err != nil {
+		return wgtypes.Key{}, err
+	}
+
+	privateKey, err := wgtypes.ParseKey(data)
+	if err != nil {
+		return wgtypes.Key{}, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+func LoadOrGeneratePrivateKey(path string) (wgtypes.Key, error) {
+	privateKey, err := LoadPrivateKey(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		return privateKey, err
+	}
+
+	privateKey, err = wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return wgtypes.Key{}, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	err = createKeyFile(path, privateKey.String())
+	if errors.Is(err, os.ErrExist) {
+		return LoadPrivateKey(path)
+	}
+	if err != nil {
+		return wgtypes.Key{}, err
+	}
+
+	slog.Info("generated private key", "path", path, "public_key", privateKey.PublicKey().String())
+	return privateKey, nil
+}
+
+// LoadSigningKey reads an ed25519 key like LoadPrivateKey.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return authority.ParsePrivateKey(data)
+}
+
+func LoadOrGenerateSigningKey(path string) (ed25519.PrivateKey, error) {
+	signingKey, err := LoadSigningKey(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		return signingKey, err
+	}
+
+	signingKey, err = authority.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	err = createKeyFile(path, authority.EncodePrivateKey(signingKey))
+	if errors.Is(err, os.ErrExist) {
+		return LoadSigningKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("generated signing key", "path", path, "public_key", authority.EncodePublicKey(signingKey.Public().(ed25519.PublicKey)))
+	return signingKey, nil
+}
+
+func readKeyFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key: %w", err)
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("%w: %s has mode %s, expected 0600", ErrInsecureKeyFile, path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// createKeyFile returns os.ErrExist if the key was written concurrently.
+func createKeyFile(path string, content string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+
+	_, err = file.WriteString(content + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+
+	return nil
+}
+
+func writePrivateKey(path string, privateKey wgtypes.Key) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	// The mode of an existing file is not changed by OpenFile.
+	err = file.Chmod(0600)
+	if err == nil {
+		_, err = file.WriteString(privateKey.String() + "\n")
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	return nil
+}
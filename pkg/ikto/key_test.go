@@ -0,0 +1,40 @@
+package ikto
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrGeneratePrivateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ikto", "private.key")
+
+	generated, err := LoadOrGeneratePrivateKey(path)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat key: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("expected mode 0600, got %s", mode)
+	}
+
+	loaded, err := LoadOrGeneratePrivateKey(path)
+	if err != nil {
+		t.Fatalf("failed to load key: %v", err)
+	}
+	if loaded != generated {
+		t.Fatal("expected the existing key to be kept")
+	}
+
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatalf("failed to chmod key: %v", err)
+	}
+	if _, err := LoadPrivateKey(path); !errors.Is(err, ErrInsecureKeyFile) {
+		t.Fatalf("expected ErrInsecureKeyFile, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package ikto
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+// SetLabels changes the labels of the local node until the agent restarts.
+func (i *Ikto) SetLabels(ctx context.Context, set map[string]string, remove []string) (types.Peer, error) {
+	if err := types.ValidateLabels(set); err != nil {
+		return types.Peer{}, err
+	}
+
+	return i.updateSelf(ctx, func(self *types.Peer) {
+		self.Labels = updateMetadata(self.Labels, set, remove)
+	})
+}
+
+func (i *Ikto) SetAnnotations(ctx context.Context, set map[string]string, remove []string) (types.Peer, error) {
+	if err := types.ValidateAnnotations(set); err != nil {
+		return types.Peer{}, err
+	}
+
+	return i.updateSelf(ctx, func(self *types.Peer) {
+		self.Annotations = updateMetadata(self.Annotations, set, remove)
+	})
+}
+
+func (i *Ikto) updateSelf(ctx context.Context, update func(self *types.Peer)) (types.Peer, error) {
+	i.leaveMutex.Lock()
+	defer i.leaveMutex.Unlock()
+
+	select {
+	case <-i.left:
+		return types.Peer{}, ErrAlreadyLeft
+	default:
+	}
+
+	self := i.Self()
+	update(&self)
+
+	revision, err := i.store.UpdatePeer(ctx, self, i.revision)
+	if err != nil {
+		return types.Peer{}, fmt.Errorf("failed to update self: %w", err)
+	}
+
+	i.keyMutex.Lock()
+	i.self = self
+	i.revision = revision
+	i.keyMutex.Unlock()
+
+	i.state.SetLabels(self.Labels)
+
+	slog.Info("updated self", "labels", self.Labels, "annotations", self.Annotations)
+	return self, nil
+}
+
+func updateMetadata(metadata map[string]string, set map[string]string, remove []string) map[string]string {
+	updated := maps.Clone(metadata)
+	if updated == nil {
+		updated = make(map[string]string, len(set))
+	}
+
+	maps.Copy(updated, set)
+	for _, key := range remove {
+		delete(updated, key)
+	}
+
+	if len(updated) == 0 {
+		return nil
+	}
+
+	return updated
+}
@@ -0,0 +1,239 @@
+package ikto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+func (i *Ikto) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(i.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		self := i.Self()
+		_, err := i.store.PutHeartbeat(ctx, self.AllowedIP, types.Heartbeat{
+			PublicKey: self.PublicKey,
+			Time:      time.Now(),
+		})
+		if err != nil && ctx.Err() == nil {
+			slog.Error("failed to publish heartbeat", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reap deletes the records of the peers that missed their grace period,
+// under the observed revision.
+func (i *Ikto) reap(ctx context.Context) {
+	ticker := time.NewTicker(i.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	addresses := time.NewTicker(i.config.PeerGracePeriod)
+	defer addresses.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-addresses.C:
+			if err := i.reapAddresses(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				slog.Error("failed to reap secondary addresses", "error", err)
+			}
+			continue
+		case <-ticker.C:
+		}
+
+		for _, status := range i.state.ListPeerStatuses() {
+			if status.Alive {
+				continue
+			}
+
+			i.reapPeer(ctx, status)
+		}
+	}
+}
+
+func (i *Ikto) reapPeer(ctx context.Context, status state.PeerStatus) {
+	peer := status.Peer
+	slog.Info("Reaping dead peer", "name", peer.Name, "public_key", peer.PublicKey.String(), "ip", peer.AllowedIP, "last_seen", status.LastSeen)
+
+	err := i.store.DeletePeer(ctx, peer.AllowedIP, status.Revision)
+	if err != nil {
+		slog.Debug("failed to reap peer", "error", err, "ip", peer.AllowedIP)
+		return
+	}
+
+	err = i.store.DeleteHeartbeat(ctx, peer.AllowedIP)
+	if err != nil {
+		slog.Error("failed to delete heartbeat", "error", err, "ip", peer.AllowedIP)
+	}
+
+	err = i.store.DeleteObservedEndpoints(ctx, peer.AllowedIP)
+	if err != nil {
+		slog.Error("failed to delete observed endpoints", "error", err, "ip", peer.AllowedIP)
+	}
+
+	if peer.SecondaryIP != "" {
+		err = i.store.ReleaseAddress(ctx, peer.SecondaryIP, peer.PublicKey)
+		if err != nil {
+			slog.Error("failed to release secondary address", "error", err, "ip", peer.SecondaryIP)
+		}
+	}
+}
+
+// reapAddresses deletes the unused reservations older than the grace period.
+func (i *Ikto) reapAddresses(ctx context.Context, now time.Time) error {
+	claims, err := i.store.ListAddressClaims(ctx)
+	if err != nil {
+		return err
+	}
+
+	peers, err := i.store.ListPeers(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending, err := i.store.ListPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool, len(peers)+len(pending))
+	for _, peer := range peers {
+		used[peer.SecondaryIP] = true
+	}
+	for _, request := range pending {
+		used[request.Peer.SecondaryIP] = true
+	}
+
+	for _, claim := range claims {
+		if used[claim.IP] || now.Sub(claim.Claimed) < i.config.PeerGracePeriod {
+			continue
+		}
+
+		slog.Info("Reaping unused secondary address", "ip", claim.IP, "public_key", claim.PublicKey.String(), "claimed", claim.Claimed)
+		err := i.store.DeleteAddressClaim(ctx, claim.IP, claim.Revision)
+		if err != nil {
+			slog.Debug("failed to reap secondary address", "error", err, "ip", claim.IP)
+		}
+	}
+
+	return nil
+}
+
+var errLeaveInProgress = errors.New("leave or key rotation in progress")
+
+// watchSelf registers the local node again when its record is reaped, or
+// leaves the mesh if its address was given to another node.
+func (i *Ikto) watchSelf(ctx context.Context) {
+	ticker := time.NewTicker(i.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted := i.state.NotifyDelete(state.PeerKey(i.Self().AllowedIP))
+		select {
+		case <-ctx.Done():
+			return
+		case <-deleted:
+		}
+
+		for {
+			err := i.rejoin(ctx)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, ErrAddressAlreadyInUse) {
+				slog.Error("address of the local node was given to another node, leaving the mesh", "error", err)
+				i.abandon()
+				return
+			}
+			if !errors.Is(err, errLeaveInProgress) && ctx.Err() == nil {
+				slog.Error("failed to register the local node again", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+func (i *Ikto) rejoin(ctx context.Context) error {
+	if !i.leaveMutex.TryLock() {
+		return errLeaveInProgress
+	}
+	defer i.leaveMutex.Unlock()
+
+	select {
+	case <-i.left:
+		return nil
+	default:
+	}
+
+	self := i.Self()
+	record, revision, err := i.store.GetPeer(ctx, self.AllowedIP)
+	if err == nil {
+		if record.PublicKey != self.PublicKey {
+			return ErrAddressAlreadyInUse
+		}
+		i.revision = revision
+		return nil
+	}
+	if !errors.Is(err, state.ErrKeyNotFound) {
+		return fmt.Errorf("failed to get self: %w", err)
+	}
+
+	slog.Warn("peer record of the local node was deleted, registering again", "allowed_ip", self.AllowedIP)
+
+	var secondaryCreated bool
+	if self.SecondaryIP != "" {
+		secondaryCreated, err = i.claimSecondary(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = i.createSelf(ctx)
+	if err != nil && secondaryCreated {
+		if releaseErr := i.store.ReleaseAddress(ctx, self.SecondaryIP, self.PublicKey); releaseErr != nil {
+			slog.Error("failed to release secondary address", "error", releaseErr, "secondary_ip", self.SecondaryIP)
+		}
+	}
+
+	return err
+}
+
+// abandon runs in one of the routines, so it does not wait for them.
+func (i *Ikto) abandon() {
+	i.leaveMutex.Lock()
+	defer i.leaveMutex.Unlock()
+
+	select {
+	case <-i.left:
+		return
+	default:
+	}
+
+	i.cancel()
+	if err := i.teardown(false); err != nil {
+		slog.Error("failed to tear down the local node", "error", err)
+		close(i.left)
+	}
+}
+
+func (i *Ikto) PeerStatuses() []state.PeerStatus {
+	return i.state.ListPeerStatuses()
+}
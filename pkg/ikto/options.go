@@ -0,0 +1,41 @@
+package ikto
+
+import (
+	"github.com/nats-io/nats.go"
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/internal/state"
+)
+
+type options struct {
+	device       network.Device
+	routeManager network.RouteManager
+	registry     state.Registry
+	nc           *nats.Conn
+}
+
+type Option func(*options)
+
+func WithDevice(device network.Device) Option {
+	return func(o *options) {
+		o.device = device
+	}
+}
+
+func WithRouteManager(routeManager network.RouteManager) Option {
+	return func(o *options) {
+		o.routeManager = routeManager
+	}
+}
+
+func WithRegistry(registry state.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithNatsConn passes a connection, which is closed by Stop.
+func WithNatsConn(nc *nats.Conn) Option {
+	return func(o *options) {
+		o.nc = nc
+	}
+}
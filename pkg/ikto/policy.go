@@ -0,0 +1,44 @@
+package ikto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+var ErrUnknownPolicy = errors.New("unknown topology policy")
+
+// Policies returns the topology policies of the mesh.
+func (i *Ikto) Policies(ctx context.Context) ([]types.Policy, error) {
+	return i.store.ListPolicies(ctx)
+}
+
+// PutPolicy creates or replaces a topology policy of the mesh. Every node
+// applies it to select its peers.
+func (i *Ikto) PutPolicy(ctx context.Context, policy types.Policy) error {
+	err := i.store.PutPolicy(ctx, policy)
+	if err != nil {
+		return fmt.Errorf("failed to put policy: %w", err)
+	}
+
+	slog.Info("put topology policy", "name", policy.Name, "nodes", policy.Nodes, "peers", policy.Peers, "same_labels", policy.SameLabels)
+	return nil
+}
+
+// DeletePolicy deletes the topology policy named name.
+func (i *Ikto) DeletePolicy(ctx context.Context, name string) error {
+	err := i.store.DeletePolicy(ctx, name)
+	if errors.Is(err, state.ErrKeyNotFound) {
+		return fmt.Errorf("%w: %s", ErrUnknownPolicy, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+
+	slog.Info("deleted topology policy", "name", name)
+	return nil
+}
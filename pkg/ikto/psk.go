@@ -0,0 +1,404 @@
+package ikto
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/internal/psk"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+const (
+	presharedKeyCheckInterval = 10 * time.Second
+	presharedKeyRetryDelay    = time.Second
+	presharedKeyMaxRetryDelay = 5 * time.Minute
+	// presharedKeyMaxConfirmDelay caps the retries when the responder may
+	// have applied a key the initiator did not.
+	presharedKeyMaxConfirmDelay = 5 * time.Second
+	presharedKeyTimeout         = 5 * time.Second
+)
+
+var errUnconfirmed = fmt.Errorf("preshared key not confirmed")
+
+// presharedKeys negotiates a preshared key with every peer over NATS. The
+// peer with the lowest public key initiates the exchanges, and the responder
+// applies the key once the initiator confirms it.
+type presharedKeys struct {
+	nc         *nats.Conn
+	prefix     string
+	privateKey wgtypes.Key
+	device     network.Device
+	rotation   time.Duration
+	peers      func() []types.Peer
+
+	mutex      sync.Mutex
+	subs       []*nats.Subscription
+	negotiated map[wgtypes.Key]time.Time
+	pending    map[wgtypes.Key]pendingPresharedKey
+	// timestamps prevent the requests from being replayed.
+	timestamps map[wgtypes.Key]int64
+	retries    map[wgtypes.Key]presharedKeyRetry
+	kick       chan struct{}
+}
+
+type presharedKeyRetry struct {
+	delay time.Duration
+	next  time.Time
+}
+
+// pendingPresharedKey waits for the confirmation of the initiator.
+type pendingPresharedKey struct {
+	key     wgtypes.Key
+	request psk.Request
+}
+
+func newPresharedKeys(nc *nats.Conn, bucket string, privateKey wgtypes.Key, device network.Device, rotation time.Duration, peers func() []types.Peer) *presharedKeys {
+	return &presharedKeys{
+		nc:         nc,
+		prefix:     "ikto.psk." + bucket,
+		privateKey: privateKey,
+		device:     device,
+		rotation:   rotation,
+		peers:      peers,
+		negotiated: make(map[wgtypes.Key]time.Time),
+		pending:    make(map[wgtypes.Key]pendingPresharedKey),
+		timestamps: make(map[wgtypes.Key]int64),
+		retries:    make(map[wgtypes.Key]presharedKeyRetry),
+		kick:       make(chan struct{}, 1),
+	}
+}
+
+func (p *presharedKeys) subject(publicKey wgtypes.Key) string {
+	return p.prefix + "." + hex.EncodeToString(publicKey[:])
+}
+
+func (p *presharedKeys) start() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.subscribe()
+}
+
+func (p *presharedKeys) run(ctx context.Context) {
+	defer func() {
+		p.mutex.Lock()
+		p.unsubscribe()
+		p.mutex.Unlock()
+	}()
+
+	ticker := time.NewTicker(presharedKeyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.initiateDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.kick:
+		}
+	}
+}
+
+// subscribe must be called with the mutex held.
+func (p *presharedKeys) subscribe() error {
+	subject := p.subject(p.privateKey.PublicKey())
+	sub, err := p.nc.Subscribe(subject, p.respond)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to preshared key requests: %w", err)
+	}
+
+	confirmSub, err := p.nc.Subscribe(subject+".confirm", p.confirm)
+	if err != nil {
+		if err := sub.Unsubscribe(); err != nil {
+			slog.Debug("failed to unsubscribe from preshared key requests", "error", err)
+		}
+		return fmt.Errorf("failed to subscribe to preshared key confirmations: %w", err)
+	}
+	p.subs = []*nats.Subscription{sub, confirmSub}
+
+	return nil
+}
+
+func (p *presharedKeys) unsubscribe() {
+	for _, sub := range p.subs {
+		if err := sub.Unsubscribe(); err != nil {
+			slog.Debug("failed to unsubscribe from preshared key requests", "error", err)
+		}
+	}
+	p.subs = nil
+}
+
+func (p *presharedKeys) setPrivateKey(privateKey wgtypes.Key) error {
+	p.mutex.Lock()
+	p.privateKey = privateKey
+	p.negotiated = make(map[wgtypes.Key]time.Time)
+	p.pending = make(map[wgtypes.Key]pendingPresharedKey)
+	p.retries = make(map[wgtypes.Key]presharedKeyRetry)
+
+	var err error
+	if len(p.subs) > 0 {
+		p.unsubscribe()
+		err = p.subscribe()
+	}
+	p.mutex.Unlock()
+
+	p.peerChanged()
+	return err
+}
+
+func (p *presharedKeys) currentKey() wgtypes.Key {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.privateKey
+}
+
+func (p *presharedKeys) peerChanged() {
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+}
+
+// renegotiate is called when a peer restarts and loses its keys.
+func (p *presharedKeys) renegotiate(publicKey wgtypes.Key) {
+	p.mutex.Lock()
+	delete(p.negotiated, publicKey)
+	delete(p.retries, publicKey)
+	p.mutex.Unlock()
+
+	p.peerChanged()
+}
+
+func (p *presharedKeys) forget(publicKey wgtypes.Key) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.negotiated, publicKey)
+	delete(p.pending, publicKey)
+	delete(p.timestamps, publicKey)
+	delete(p.retries, publicKey)
+}
+
+func (p *presharedKeys) negotiatedAt(publicKey wgtypes.Key) (time.Time, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	at, ok := p.negotiated[publicKey]
+	return at, ok
+}
+
+func (p *presharedKeys) retryAt(publicKey wgtypes.Key) time.Time {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.retries[publicKey].next
+}
+
+// backoff caps the delay at presharedKeyMaxConfirmDelay when unconfirmed.
+func (p *presharedKeys) backoff(publicKey wgtypes.Key, now time.Time, unconfirmed bool) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	maxDelay := presharedKeyMaxRetryDelay
+	if unconfirmed {
+		maxDelay = presharedKeyMaxConfirmDelay
+	}
+
+	retry := p.retries[publicKey]
+	retry.delay = min(max(2*retry.delay, presharedKeyRetryDelay), maxDelay)
+	retry.next = now.Add(retry.delay)
+	p.retries[publicKey] = retry
+
+	return retry.delay
+}
+
+func (p *presharedKeys) initiateDue(ctx context.Context) {
+	self := p.currentKey().PublicKey()
+
+	var retry time.Duration
+	for _, peer := range p.peers() {
+		publicKey := peer.PublicKey.WG()
+		if bytes.Compare(self[:], publicKey[:]) >= 0 {
+			continue
+		}
+
+		at, ok := p.negotiatedAt(publicKey)
+		if ok && (p.rotation <= 0 || time.Since(at) < p.rotation) {
+			continue
+		}
+		if time.Now().Before(p.retryAt(publicKey)) {
+			continue
+		}
+
+		if err := p.initiate(ctx, publicKey); err != nil {
+			delay := p.backoff(publicKey, time.Now(), errors.Is(err, errUnconfirmed))
+			slog.Warn("failed to negotiate preshared key", "error", err, "public_key", peer.PublicKey.String(), "retry_in", delay)
+			if retry == 0 || delay < retry {
+				retry = delay
+			}
+		}
+	}
+
+	if retry > 0 && retry < presharedKeyCheckInterval {
+		time.AfterFunc(retry, p.peerChanged)
+	}
+}
+
+func (p *presharedKeys) initiate(ctx context.Context, publicKey wgtypes.Key) error {
+	initiator, request, err := psk.Initiate(p.currentKey(), publicKey, time.Now())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, presharedKeyTimeout)
+	defer cancel()
+
+	msg, err := p.nc.RequestWithContext(ctx, p.subject(publicKey), data)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var response psk.Response
+	if err := json.Unmarshal(msg.Data, &response); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	key, err := initiator.Finish(response)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(initiator.Confirm(key))
+	if err != nil {
+		return err
+	}
+
+	// The responder may have applied the key if the acknowledgment is lost.
+	if _, err := p.nc.RequestWithContext(ctx, p.subject(publicKey)+".confirm", data); err != nil {
+		return fmt.Errorf("%w: %w", errUnconfirmed, err)
+	}
+
+	return p.apply(publicKey, key)
+}
+
+func (p *presharedKeys) respond(msg *nats.Msg) {
+	var request psk.Request
+	if err := json.Unmarshal(msg.Data, &request); err != nil {
+		slog.Warn("failed to read preshared key request", "error", err)
+		return
+	}
+
+	initiator := request.Initiator.WG()
+	if !slices.ContainsFunc(p.peers(), func(peer types.Peer) bool {
+		return peer.PublicKey.WG() == initiator
+	}) {
+		slog.Warn("ignoring preshared key request from unknown peer", "public_key", request.Initiator.String())
+		return
+	}
+
+	response, key, err := psk.Respond(p.currentKey(), request, time.Now())
+	if err != nil {
+		slog.Warn("rejecting preshared key request", "error", err, "public_key", request.Initiator.String())
+		return
+	}
+
+	p.mutex.Lock()
+	if request.Timestamp <= p.timestamps[initiator] {
+		p.mutex.Unlock()
+		slog.Warn("rejecting replayed preshared key request", "public_key", request.Initiator.String())
+		return
+	}
+	p.timestamps[initiator] = request.Timestamp
+	p.pending[initiator] = pendingPresharedKey{key: key, request: request}
+	p.mutex.Unlock()
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		slog.Error("failed to encode preshared key response", "error", err)
+		return
+	}
+
+	if err := msg.Respond(data); err != nil {
+		slog.Warn("failed to send preshared key response", "error", err)
+	}
+}
+
+// confirm applies the pending key of the initiator and acknowledges it.
+func (p *presharedKeys) confirm(msg *nats.Msg) {
+	var confirmation psk.Confirmation
+	if err := json.Unmarshal(msg.Data, &confirmation); err != nil {
+		slog.Warn("failed to read preshared key confirmation", "error", err)
+		return
+	}
+	initiator := confirmation.Initiator.WG()
+
+	p.mutex.Lock()
+	pending, ok := p.pending[initiator]
+	var err error
+	if ok {
+		err = psk.VerifyConfirmation(pending.key, pending.request, confirmation)
+		if err == nil {
+			delete(p.pending, initiator)
+		}
+	}
+	p.mutex.Unlock()
+
+	if !ok {
+		slog.Warn("ignoring preshared key confirmation without request", "public_key", confirmation.Initiator.String())
+		return
+	}
+	if err != nil {
+		slog.Warn("rejecting preshared key confirmation", "error", err, "public_key", confirmation.Initiator.String())
+		return
+	}
+
+	if err := p.apply(initiator, pending.key); err != nil {
+		slog.Error("failed to apply preshared key", "error", err, "public_key", confirmation.Initiator.String())
+		return
+	}
+
+	if err := msg.Respond(nil); err != nil {
+		slog.Warn("failed to acknowledge preshared key confirmation", "error", err)
+	}
+}
+
+func (p *presharedKeys) apply(publicKey wgtypes.Key, key wgtypes.Key) error {
+	if err := p.device.SetPresharedKey(publicKey, &key); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.negotiated[publicKey] = time.Now()
+	delete(p.retries, publicKey)
+	p.mutex.Unlock()
+
+	slog.Info("negotiated preshared key", "public_key", publicKey.String())
+	return nil
+}
+
+func (i *Ikto) PresharedKeyNegotiated(publicKey types.PublicKey) (time.Time, bool) {
+	if i.presharedKeys == nil {
+		return time.Time{}, false
+	}
+
+	return i.presharedKeys.negotiatedAt(publicKey.WG())
+}
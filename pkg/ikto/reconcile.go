@@ -0,0 +1,129 @@
+package ikto
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/network"
+)
+
+// ReconcileMetrics records what the reconciliation loop found and corrected.
+type ReconcileMetrics struct {
+	Runs            uint64
+	Failures        uint64
+	DriftedRuns     uint64
+	PrivateKeyFixes uint64
+	ListenPortFixes uint64
+	TuningFixes     uint64
+	AddedPeers      uint64
+	RemovedPeers    uint64
+	UpdatedPeers    uint64
+	LastRun         time.Time
+	LastError       string
+}
+
+type reconcileMetrics struct {
+	mutex   sync.Mutex
+	metrics ReconcileMetrics
+}
+
+func (m *reconcileMetrics) record(drift network.Drift, dryRun bool, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.metrics.Runs++
+	m.metrics.LastRun = time.Now()
+	if err != nil {
+		m.metrics.Failures++
+		m.metrics.LastError = err.Error()
+	}
+
+	if drift.Empty() {
+		return
+	}
+
+	m.metrics.DriftedRuns++
+	if dryRun {
+		return
+	}
+
+	if drift.PrivateKey {
+		m.metrics.PrivateKeyFixes++
+	}
+	if drift.ListenPort {
+		m.metrics.ListenPortFixes++
+	}
+	if drift.Tuning {
+		m.metrics.TuningFixes++
+	}
+	m.metrics.AddedPeers += uint64(len(drift.MissingPeers))
+	m.metrics.RemovedPeers += uint64(len(drift.UnknownPeers))
+	m.metrics.UpdatedPeers += uint64(len(drift.ModifiedPeers))
+}
+
+func (m *reconcileMetrics) get() ReconcileMetrics {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.metrics
+}
+
+func (i *Ikto) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(i.config.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, _ = i.Reconcile(i.config.ReconcileDryRun)
+	}
+}
+
+// Reconcile compares the WireGuard device with the synchronized peers and
+// corrects any drift unless dryRun is set.
+func (i *Ikto) Reconcile(dryRun bool) (network.Drift, error) {
+	i.reconcileMutex.Lock()
+	defer i.reconcileMutex.Unlock()
+
+	select {
+	case <-i.left:
+		return network.Drift{}, ErrAlreadyLeft
+	default:
+	}
+
+	i.keyMutex.RLock()
+	privateKey := i.privateKey
+	i.keyMutex.RUnlock()
+
+	_, exitActive := i.routes.getExit()
+	drift, err := network.Reconcile(i.wg, privateKey, i.config.WGPort, i.config.tuning(), exitActive, i.routes.resolve(i.state.ListPeers()), dryRun)
+	i.reconcileMetrics.record(drift, dryRun, err)
+	if err != nil {
+		slog.Error("failed to reconcile wireguard device", "error", err)
+		return drift, err
+	}
+
+	if !drift.Empty() {
+		slog.Warn("wireguard device drifted from desired state",
+			"dry_run", dryRun,
+			"private_key", drift.PrivateKey,
+			"listen_port", drift.ListenPort,
+			"tuning", drift.Tuning,
+			"missing_peers", len(drift.MissingPeers),
+			"unknown_peers", len(drift.UnknownPeers),
+			"modified_peers", len(drift.ModifiedPeers),
+		)
+	}
+
+	return drift, nil
+}
+
+func (i *Ikto) ReconcileMetrics() ReconcileMetrics {
+	return i.reconcileMetrics.get()
+}
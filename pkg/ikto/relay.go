@@ -0,0 +1,280 @@
+package ikto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// RelayPath describes a peer reached through a relay. Requested is set when
+// the peer relays the local node, which then uses the same relay.
+type RelayPath struct {
+	Relay     types.PublicKey
+	Since     time.Time
+	Requested bool
+}
+
+// relays moves the allowed IPs of the peers without handshake for the
+// timeout to a relay, until the direct path completes a handshake. The
+// relayed peers are published so that they move the allowed IPs of the
+// local node to the same relay.
+type relays struct {
+	store    *state.Store
+	device   network.Device
+	routes   *routeTable
+	timeout  time.Duration
+	interval time.Duration
+	self     func() types.Peer
+	peers    func() []state.PeerStatus
+	remote   func() []types.RelayPaths
+
+	mutex       sync.Mutex
+	paths       map[types.PublicKey]RelayPath
+	transmitted map[types.PublicKey]int64
+	tracked     map[types.PublicKey]time.Time
+	// published is nil until the relayed peers are first published.
+	published *types.RelayPaths
+}
+
+func newRelays(store *state.Store, device network.Device, routes *routeTable, timeout time.Duration, interval time.Duration, self func() types.Peer, peers func() []state.PeerStatus, remote func() []types.RelayPaths) *relays {
+	return &relays{
+		store:       store,
+		device:      device,
+		routes:      routes,
+		timeout:     timeout,
+		interval:    interval,
+		self:        self,
+		peers:       peers,
+		remote:      remote,
+		paths:       make(map[types.PublicKey]RelayPath),
+		transmitted: make(map[types.PublicKey]int64),
+		tracked:     make(map[types.PublicKey]time.Time),
+	}
+}
+
+func (r *relays) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.check(ctx, time.Now()); err != nil {
+			slog.Error("failed to check the direct paths to the peers", "error", err)
+		}
+	}
+}
+
+func (r *relays) check(ctx context.Context, now time.Time) error {
+	device, err := r.device.Device()
+	if err != nil {
+		return err
+	}
+
+	wgPeers := make(map[wgtypes.Key]wgtypes.Peer, len(device.Peers))
+	for _, wgPeer := range device.Peers {
+		wgPeers[wgPeer.PublicKey] = wgPeer
+	}
+
+	statuses := r.peers()
+	candidates := []types.Peer{}
+	for _, status := range statuses {
+		if status.Selected && status.Alive && status.Peer.Relay {
+			candidates = append(candidates, status.Peer)
+		}
+	}
+
+	r.mutex.Lock()
+	paths := make(map[types.PublicKey]RelayPath)
+	seen := make(map[types.PublicKey]bool)
+	for _, status := range statuses {
+		peer := status.Peer
+		wgPeer, ok := wgPeers[peer.PublicKey.WG()]
+		if !ok || !status.Selected || peer.Relay {
+			continue
+		}
+		seen[peer.PublicKey] = true
+
+		transmitted := wgPeer.TransmitBytes
+		previous, ok := r.transmitted[peer.PublicKey]
+		sending := ok && transmitted > previous
+		r.transmitted[peer.PublicKey] = transmitted
+		if _, ok := r.tracked[peer.PublicKey]; !ok {
+			r.tracked[peer.PublicKey] = now
+		}
+
+		if path, ok := r.paths[peer.PublicKey]; ok && !path.Requested {
+			if wgPeer.LastHandshakeTime.After(path.Since) {
+				slog.Info("direct path to peer restored", "name", peer.Name, "public_key", peer.PublicKey.String())
+				continue
+			}
+
+			if !slices.ContainsFunc(candidates, func(candidate types.Peer) bool {
+				return candidate.PublicKey == path.Relay
+			}) {
+				relay, ok := selectRelay(candidates, peer)
+				if !ok {
+					slog.Warn("no relay left for unreachable peer", "name", peer.Name, "public_key", peer.PublicKey.String())
+					continue
+				}
+				path.Relay = relay.PublicKey
+				slog.Info("relaying unreachable peer", "name", peer.Name, "public_key", peer.PublicKey.String(), "relay", peerName(relay))
+			}
+			paths[peer.PublicKey] = path
+			continue
+		}
+
+		since := wgPeer.LastHandshakeTime
+		if tracked := r.tracked[peer.PublicKey]; since.Before(tracked) {
+			since = tracked
+		}
+		if !status.Alive || !sending || now.Sub(since) < r.timeout {
+			continue
+		}
+
+		relay, ok := selectRelay(candidates, peer)
+		if !ok {
+			continue
+		}
+		slog.Info("relaying unreachable peer", "name", peer.Name, "public_key", peer.PublicKey.String(), "relay", peerName(relay), "last_handshake", wgPeer.LastHandshakeTime)
+		paths[peer.PublicKey] = RelayPath{
+			Relay: relay.PublicKey,
+			Since: now,
+		}
+	}
+
+	for key := range r.tracked {
+		if !seen[key] {
+			delete(r.tracked, key)
+			delete(r.transmitted, key)
+		}
+	}
+
+	self := r.self()
+	r.follow(paths, self, seen, candidates, now)
+
+	changed := !maps.Equal(paths, r.paths)
+	r.paths = paths
+	r.mutex.Unlock()
+
+	if changed {
+		next := make(map[types.PublicKey]types.PublicKey, len(paths))
+		for key, path := range paths {
+			next[key] = path.Relay
+		}
+		r.routes.setRelays(next)
+	}
+
+	return r.publish(ctx, self, paths, now)
+}
+
+// follow relays the peers relaying the local node through the same relay.
+// When both ends relay each other, the choice of the lowest public key wins.
+// It must be called with the mutex held.
+func (r *relays) follow(paths map[types.PublicKey]RelayPath, self types.Peer, seen map[types.PublicKey]bool, candidates []types.Peer, now time.Time) {
+	for _, remote := range r.remote() {
+		relay, ok := remote.Relays[self.PublicKey.String()]
+		if !ok || !seen[remote.PublicKey] {
+			continue
+		}
+		if path, ok := paths[remote.PublicKey]; ok && bytes.Compare(self.PublicKey[:], remote.PublicKey[:]) < 0 {
+			continue
+		} else if ok && path.Relay.String() == relay {
+			continue
+		}
+
+		index := slices.IndexFunc(candidates, func(candidate types.Peer) bool {
+			return candidate.PublicKey.String() == relay
+		})
+		if index < 0 {
+			continue
+		}
+
+		path := RelayPath{Relay: candidates[index].PublicKey, Since: now, Requested: true}
+		if previous, ok := r.paths[remote.PublicKey]; ok && previous.Relay == path.Relay {
+			path.Since = previous.Since
+		}
+		if _, ok := r.paths[remote.PublicKey]; !ok {
+			slog.Info("relaying peer relaying the local node", "name", remote.Name, "public_key", remote.PublicKey.String(), "relay", relay)
+		}
+		paths[remote.PublicKey] = path
+	}
+}
+
+// publish writes the peers the local node detected as unreachable, unless
+// they are already published.
+func (r *relays) publish(ctx context.Context, self types.Peer, paths map[types.PublicKey]RelayPath, now time.Time) error {
+	relayed := make(map[string]string)
+	for key, path := range paths {
+		if !path.Requested {
+			relayed[key.String()] = path.Relay.String()
+		}
+	}
+
+	r.mutex.Lock()
+	published := r.published
+	r.mutex.Unlock()
+	if published != nil && published.Name == self.Name && published.PublicKey == self.PublicKey && maps.Equal(published.Relays, relayed) {
+		return nil
+	}
+
+	record := types.RelayPaths{
+		Name:      self.Name,
+		PublicKey: self.PublicKey,
+		Relays:    relayed,
+		Time:      now,
+	}
+	if err := r.store.PutRelayPaths(ctx, self.AllowedIP, record); err != nil {
+		return fmt.Errorf("failed to publish relays: %w", err)
+	}
+
+	r.mutex.Lock()
+	r.published = &record
+	r.mutex.Unlock()
+
+	return nil
+}
+
+func (r *relays) get() map[types.PublicKey]RelayPath {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return maps.Clone(r.paths)
+}
+
+// selectRelay lets every node pick the same relay for a peer.
+func selectRelay(candidates []types.Peer, peer types.Peer) (types.Peer, bool) {
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(candidate types.Peer) bool {
+		return candidate.PublicKey == peer.PublicKey
+	})
+	if len(candidates) == 0 {
+		return types.Peer{}, false
+	}
+
+	return slices.MinFunc(candidates, func(a, b types.Peer) int {
+		return bytes.Compare(a.PublicKey[:], b.PublicKey[:])
+	}), true
+}
+
+// RelayedPeers returns the peers currently reached through a relay.
+func (i *Ikto) RelayedPeers() map[types.PublicKey]RelayPath {
+	if i.relays == nil {
+		return nil
+	}
+
+	return i.relays.get()
+}
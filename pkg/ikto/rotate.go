@@ -0,0 +1,190 @@
+package ikto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/state"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// pendingKeySuffix stores a new key until the peer record references it.
+const pendingKeySuffix = ".next"
+
+var ErrCertifiedKey = fmt.Errorf("the wireguard key is bound to the certificate of the node and cannot be rotated")
+
+// RotateKey replaces the WireGuard key of the local node without leaving the
+// mesh.
+func (i *Ikto) RotateKey(ctx context.Context) (types.PublicKey, error) {
+	i.leaveMutex.Lock()
+	defer i.leaveMutex.Unlock()
+
+	return i.rotateKey(ctx)
+}
+
+func (i *Ikto) rotateKey(ctx context.Context) (types.PublicKey, error) {
+	select {
+	case <-i.left:
+		return types.PublicKey{}, ErrAlreadyLeft
+	default:
+	}
+
+	if i.config.AuthorityPublicKey != nil {
+		return types.PublicKey{}, ErrCertifiedKey
+	}
+
+	privateKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return types.PublicKey{}, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	previous := i.Self()
+	self := previous
+	self.PublicKey = types.PublicKey(privateKey.PublicKey())
+
+	// An agent restarted in between completes the rotation.
+	pendingPath := i.config.PrivateKeyPath + pendingKeySuffix
+	err = writePrivateKey(pendingPath, privateKey)
+	if err != nil {
+		return types.PublicKey{}, err
+	}
+
+	i.state.Ignore(self.PublicKey)
+
+	if i.config.RequireApproval {
+		err = i.approveRotation(ctx, previous.PublicKey, self.PublicKey)
+		if err != nil {
+			if removeErr := os.Remove(pendingPath); removeErr != nil {
+				slog.Error("failed to remove pending private key", "error", removeErr)
+			}
+			return types.PublicKey{}, fmt.Errorf("failed to approve new key: %w", err)
+		}
+	}
+
+	revision, err := i.store.UpdatePeer(ctx, self, i.revision)
+	if err != nil {
+		if removeErr := os.Remove(pendingPath); removeErr != nil {
+			slog.Error("failed to remove pending private key", "error", removeErr)
+		}
+		return types.PublicKey{}, fmt.Errorf("failed to update self: %w", err)
+	}
+
+	err = i.adoptKey(ctx, privateKey, previous.PublicKey, previous.SecondaryIP, revision)
+	if err != nil {
+		return types.PublicKey{}, err
+	}
+
+	slog.Info("rotated private key", "previous_public_key", previous.PublicKey.String(), "public_key", self.PublicKey.String())
+	return self.PublicKey, nil
+}
+
+func (i *Ikto) adoptKey(ctx context.Context, privateKey wgtypes.Key, previous types.PublicKey, secondaryIP string, revision uint64) error {
+	publicKey := types.PublicKey(privateKey.PublicKey())
+
+	err := os.Rename(i.config.PrivateKeyPath+pendingKeySuffix, i.config.PrivateKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to persist private key: %w", err)
+	}
+
+	if secondaryIP != "" {
+		err = i.store.TransferAddress(ctx, secondaryIP, previous, publicKey)
+		if err != nil {
+			return fmt.Errorf("failed to transfer secondary address: %w", err)
+		}
+	}
+
+	if i.config.RequireApproval {
+		if err := i.store.DeleteApproval(ctx, previous); err != nil {
+			slog.Error("failed to delete approval of the previous key", "error", err)
+		}
+	}
+
+	i.reconcileMutex.Lock()
+	err = i.wg.SetPrivateKey(privateKey)
+	if err == nil {
+		i.keyMutex.Lock()
+		i.privateKey = privateKey
+		i.self.PublicKey = publicKey
+		i.revision = revision
+		i.keyMutex.Unlock()
+	}
+	i.reconcileMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to set private key: %w", err)
+	}
+
+	if i.presharedKeys != nil {
+		if err := i.presharedKeys.setPrivateKey(privateKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resumeRotation completes or discards an interrupted rotation.
+func (i *Ikto) resumeRotation(ctx context.Context) error {
+	if i.config.PrivateKeyPath == "" {
+		return nil
+	}
+
+	pendingPath := i.config.PrivateKeyPath + pendingKeySuffix
+	privateKey, err := LoadPrivateKey(pendingPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("pending key: %w", err)
+	}
+
+	address := i.address
+	if address == nil {
+		address, err = i.loadAddress(i.config.MeshIPNet)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if address != nil {
+		record, revision, err := i.store.GetPeer(ctx, i.config.getPrivateCIDR(address))
+		if err != nil && !errors.Is(err, state.ErrKeyNotFound) {
+			return fmt.Errorf("failed to get self: %w", err)
+		}
+
+		if err == nil && record.PublicKey.WG() == privateKey.PublicKey() {
+			slog.Info("resuming interrupted key rotation", "public_key", record.PublicKey.String())
+			i.state.Ignore(record.PublicKey)
+			return i.adoptKey(ctx, privateKey, i.self.PublicKey, record.SecondaryIP, revision)
+		}
+	}
+
+	slog.Warn("discarding pending private key of an interrupted key rotation")
+	return os.Remove(pendingPath)
+}
+
+func (i *Ikto) rotateKeyLoop(ctx context.Context) {
+	ticker := time.NewTicker(i.config.KeyRotationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !i.leaveMutex.TryLock() {
+			continue
+		}
+		_, err := i.rotateKey(ctx)
+		i.leaveMutex.Unlock()
+		if err != nil {
+			slog.Error("failed to rotate private key", "error", err)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package ikto
+
+import (
+	"cmp"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/valyentdev/ikto/internal/network"
+	"github.com/valyentdev/ikto/pkg/types"
+)
+
+// RouteConflict describes an advertised route that is not installed because
+// it overlaps a route or a subnet it does not own.
+type RouteConflict struct {
+	Route string
+	Peer  string
+	With  string
+}
+
+// routeTable configures the routes advertised by the peers. WireGuard moves
+// an allowed IP to the last peer configured with it, so overlapping routes
+// are rejected until the overlap disappears.
+type routeTable struct {
+	// mutex also serializes the updates of the device.
+	mutex        sync.Mutex
+	device       network.Device
+	routeManager network.RouteManager
+	reserved     []net.IPNet
+	peers        map[string]types.Peer
+	effective    map[string]types.Peer
+	conflicts    []RouteConflict
+
+	// exit is the name or public key of the exit node, if any.
+	exit       string
+	exitActive bool
+	// defaultRoute is nil until the default route is first applied.
+	defaultRoute *bool
+	relays       map[types.PublicKey]types.PublicKey
+	observed     map[types.PublicKey]string
+	selected     map[types.PublicKey]string
+	keepalive    time.Duration
+}
+
+func newRouteTable(device network.Device, routeManager network.RouteManager, reserved []net.IPNet, exit string, keepalive time.Duration) *routeTable {
+	return &routeTable{
+		device:       device,
+		routeManager: routeManager,
+		reserved:     reserved,
+		peers:        make(map[string]types.Peer),
+		effective:    make(map[string]types.Peer),
+		exit:         exit,
+		keepalive:    keepalive,
+	}
+}
+
+// replace sets the peers and replaces every peer of the device.
+func (t *routeTable) replace(peers map[string]types.Peer) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.peers = make(map[string]types.Peer, len(peers))
+	for _, peer := range peers {
+		t.peers[peer.AllowedIP] = peer
+	}
+	t.update()
+
+	effective := make([]types.Peer, 0, len(t.effective))
+	routes := make(map[string][]net.IPNet, len(t.effective))
+	for key, peer := range t.effective {
+		effective = append(effective, peer)
+		routes[key] = peerRoutes(peer)
+	}
+
+	err := t.device.ReplacePeers(effective)
+	if err != nil {
+		slog.Error("failed to replace peers", "error", err)
+	}
+
+	err = t.routeManager.Sync(routes)
+	if err != nil {
+		slog.Error("failed to sync routes", "error", err)
+	}
+	t.setDefaultRoute()
+}
+
+// put adds or updates peer, and the peers whose accepted routes changed.
+func (t *routeTable) put(peer types.Peer) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	previous, replaced := t.peers[peer.AllowedIP]
+	t.peers[peer.AllowedIP] = peer
+	changed := t.update()
+	if !slices.Contains(changed, peer.AllowedIP) {
+		changed = append(changed, peer.AllowedIP)
+	}
+
+	if replaced && previous.PublicKey != peer.PublicKey {
+		t.swapPeer(previous.PublicKey, peer.AllowedIP)
+		changed = slices.DeleteFunc(changed, func(key string) bool {
+			return key == peer.AllowedIP
+		})
+	}
+
+	t.addPeers(changed)
+	t.setDefaultRoute()
+}
+
+func (t *routeTable) swapPeer(previous types.PublicKey, key string) {
+	peer, ok := t.effective[key]
+	if !ok {
+		err := t.device.RemovePeer(previous.WG())
+		if err != nil {
+			slog.Error("failed to remove peer", "error", err, "public_key", previous.String())
+		}
+		return
+	}
+
+	slog.Info("swapping rotated peer key", "previous_public_key", previous.String(), "public_key", peer.PublicKey.String())
+	err := t.device.SwapPeer(previous.WG(), peer)
+	if err != nil {
+		slog.Error("failed to swap peer", "error", err, "public_key", peer.PublicKey.String())
+	}
+
+	err = t.routeManager.SetPeerRoutes(key, peerRoutes(peer))
+	if err != nil {
+		slog.Error("failed to set peer routes", "error", err, "public_key", peer.PublicKey.String())
+	}
+}
+
+func (t *routeTable) delete(peer types.Peer) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	err := t.device.RemovePeer(peer.PublicKey.WG())
+	if err != nil {
+		slog.Error("failed to remove peer", "error", err, "public_key", peer.PublicKey.String())
+	}
+
+	err = t.routeManager.RemovePeerRoutes(peer.AllowedIP)
+	if err != nil {
+		slog.Error("failed to remove peer routes", "error", err, "public_key", peer.PublicKey.String())
+	}
+
+	delete(t.peers, peer.AllowedIP)
+	t.addPeers(t.update())
+	t.setDefaultRoute()
+}
+
+func (t *routeTable) setExit(exit string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.exit = exit
+	t.addPeers(t.update())
+	t.setDefaultRoute()
+}
+
+func (t *routeTable) setRelays(relays map[types.PublicKey]types.PublicKey) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.relays = relays
+	t.addPeers(t.update())
+	t.setDefaultRoute()
+}
+
+func (t *routeTable) setObservedEndpoints(endpoints map[types.PublicKey]string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.observed = endpoints
+	t.addPeers(t.update())
+}
+
+func (t *routeTable) setSelectedEndpoints(endpoints map[types.PublicKey]string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.selected = endpoints
+	t.addPeers(t.update())
+}
+
+// getExit returns the selected exit node and whether it is active.
+func (t *routeTable) getExit() (string, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.exit, t.exitActive
+}
+
+func (t *routeTable) addPeers(keys []string) {
+	for _, key := range keys {
+		peer, ok := t.effective[key]
+		if !ok {
+			continue
+		}
+
+		err := t.device.AddPeer(peer)
+		if err != nil {
+			slog.Error("failed to add peer", "error", err, "public_key", peer.PublicKey.String())
+		}
+
+		err = t.routeManager.SetPeerRoutes(key, peerRoutes(peer))
+		if err != nil {
+			slog.Error("failed to set peer routes", "error", err, "public_key", peer.PublicKey.String())
+		}
+	}
+}
+
+// peerRoutes returns the kernel routes of peer, except the default routes,
+// which are installed with policy routing.
+func peerRoutes(peer types.Peer) []net.IPNet {
+	if peer.RelayedBy != nil {
+		return nil
+	}
+
+	routes, err := peer.Subnets()
+	if err != nil {
+		return nil
+	}
+
+	advertised, err := peer.AdvertisedRoutes()
+	if err != nil {
+		return routes
+	}
+	for _, route := range advertised {
+		if ones, _ := route.Mask.Size(); ones > 0 {
+			routes = append(routes, route)
+		}
+	}
+
+	return routes
+}
+
+// update returns the keys of the peers whose accepted routes changed.
+func (t *routeTable) update() []string {
+	peers := make([]types.Peer, 0, len(t.peers))
+	for _, peer := range t.peers {
+		peers = append(peers, peer)
+	}
+
+	effective, conflicts, exitActive := t.configure(peers)
+	t.exitActive = exitActive
+
+	changed := []string{}
+	next := make(map[string]types.Peer, len(effective))
+	for _, peer := range effective {
+		next[peer.AllowedIP] = peer
+		if previous, ok := t.effective[peer.AllowedIP]; !ok || !sameConfiguration(previous, peer) {
+			changed = append(changed, peer.AllowedIP)
+		}
+	}
+	t.effective = next
+
+	for _, conflict := range conflicts {
+		if !slices.Contains(t.conflicts, conflict) {
+			slog.Warn("ignoring conflicting route", "route", conflict.Route, "peer", conflict.Peer, "overlaps", conflict.With)
+		}
+	}
+	t.conflicts = conflicts
+
+	return changed
+}
+
+func (t *routeTable) setDefaultRoute() {
+	active := t.exitActive
+	if t.defaultRoute != nil && *t.defaultRoute == active {
+		return
+	}
+
+	err := t.device.SetDefaultRoute(active)
+	if err != nil {
+		slog.Error("failed to set default route", "error", err)
+		return
+	}
+	t.defaultRoute = &active
+}
+
+// resolve returns the effective peers without recording them.
+func (t *routeTable) resolve(peers []types.Peer) []types.Peer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	effective, _, _ := t.configure(peers)
+	return effective
+}
+
+func (t *routeTable) configure(peers []types.Peer) ([]types.Peer, []RouteConflict, bool) {
+	effective, conflicts := resolveRoutes(t.reserved, peers)
+	exitActive := selectExit(effective, t.exit)
+	relayPeers(effective, t.relays)
+
+	for index, peer := range effective {
+		candidates := peer.Candidates()
+		if endpoint, ok := t.selected[peer.PublicKey]; ok && slices.Contains(candidates, endpoint) {
+			effective[index].Endpoint = endpoint
+		}
+		keepalive := t.keepalive
+		if peer.Tuning.PersistentKeepalive != 0 {
+			keepalive = peer.Tuning.Keepalive()
+		}
+		if endpoint, ok := t.observed[peer.PublicKey]; ok && len(candidates) == 0 {
+			effective[index].Endpoint = endpoint
+			if keepalive == 0 {
+				keepalive = types.NATKeepaliveInterval
+			}
+		}
+		effective[index].Keepalive = keepalive
+	}
+
+	return effective, conflicts, exitActive
+}
+
+func sameConfiguration(a types.Peer, b types.Peer) bool {
+	return slices.Equal(a.Routes, b.Routes) &&
+		equalKeys(a.RelayedBy, b.RelayedBy) &&
+		a.Endpoint == b.Endpoint &&
+		a.Keepalive == b.Keepalive
+}
+
+func selectExit(peers []types.Peer, exit string) bool {
+	if exit == "" {
+		return false
+	}
+
+	for index, peer := range peers {
+		if !isExit(peer, exit) {
+			continue
+		}
+
+		peers[index].Routes = append(slices.Clone(peer.Routes), defaultRoutes...)
+		return true
+	}
+
+	return false
+}
+
+// relayPeers moves the routes of the relayed peers to their relay.
+func relayPeers(peers []types.Peer, relays map[types.PublicKey]types.PublicKey) {
+	if len(relays) == 0 {
+		return
+	}
+
+	indexes := make(map[types.PublicKey]int, len(peers))
+	for index, peer := range peers {
+		indexes[peer.PublicKey] = index
+	}
+
+	for index, peer := range peers {
+		relay, ok := relays[peer.PublicKey]
+		if !ok {
+			continue
+		}
+		relayIndex, ok := indexes[relay]
+		if !ok || relayIndex == index {
+			continue
+		}
+
+		subnets, err := peer.Subnets()
+		if err != nil {
+			continue
+		}
+
+		routes := slices.Clone(peers[relayIndex].Routes)
+		for _, subnet := range subnets {
+			routes = append(routes, subnet.String())
+		}
+		peers[relayIndex].Routes = append(routes, peer.Routes...)
+		peers[index].RelayedBy = &relay
+	}
+}
+
+func equalKeys(a, b *types.PublicKey) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func (t *routeTable) getConflicts() []RouteConflict {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return slices.Clone(t.conflicts)
+}
+
+// resolveRoutes drops the routes overlapping anything they do not own.
+func resolveRoutes(reserved []net.IPNet, peers []types.Peer) ([]types.Peer, []RouteConflict) {
+	slices.SortFunc(peers, func(a, b types.Peer) int {
+		return cmp.Compare(a.AllowedIP, b.AllowedIP)
+	})
+
+	routes := make([][]net.IPNet, len(peers))
+	subnets := make([][]net.IPNet, len(peers))
+	for index, peer := range peers {
+		peerSubnets, err := peer.Subnets()
+		if err == nil {
+			subnets[index] = peerSubnets
+		}
+
+		for _, route := range peer.Routes {
+			_, ipnet, err := net.ParseCIDR(route)
+			if err != nil {
+				slog.Error("ignoring invalid route", "error", err, "route", route, "peer", peerName(peer))
+				continue
+			}
+			routes[index] = append(routes[index], *ipnet)
+		}
+	}
+
+	effective := make([]types.Peer, 0, len(peers))
+	conflicts := []RouteConflict{}
+	for index, peer := range peers {
+		accepted := []string{}
+		for _, route := range routes[index] {
+			with := ""
+			if network.OverlapsAny(route, reserved) {
+				with = "mesh network or local routes"
+			}
+			for other := range peers {
+				if with != "" {
+					break
+				}
+				if other == index {
+					continue
+				}
+				if network.OverlapsAny(route, routes[other]) || network.OverlapsAny(route, subnets[other]) {
+					with = peerName(peers[other])
+				}
+			}
+
+			if with != "" {
+				conflicts = append(conflicts, RouteConflict{
+					Route: route.String(),
+					Peer:  peerName(peer),
+					With:  with,
+				})
+				continue
+			}
+
+			accepted = append(accepted, route.String())
+		}
+
+		peer.Routes = nil
+		if len(accepted) > 0 {
+			peer.Routes = accepted
+		}
+		effective = append(effective, peer)
+	}
+
+	return effective, conflicts
+}
+
+var defaultRoutes = []string{"0.0.0.0/0", "::/0"}
+
+func routeFamilies(routes []net.IPNet) []int {
+	families := []int{}
+	for _, route := range routes {
+		family := network.Family(route.IP)
+		if !slices.Contains(families, family) {
+			families = append(families, family)
+		}
+	}
+
+	return families
+}
+
+func peerName(peer types.Peer) string {
+	if peer.Name != "" {
+		return peer.Name
+	}
+	return peer.PublicKey.String()
+}
@@ -2,15 +2,19 @@ package server
 
 import (
 	"context"
+	"log/slog"
 	"net"
+	"time"
 
 	"github.com/valyentdev/ikto/pkg/ikto"
 	"github.com/valyentdev/ikto/pkg/proto"
+	"github.com/valyentdev/ikto/pkg/types"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-func StartAdminServer(ctx context.Context, ikto ikto.Ikto, socket string) error {
+func StartAdminServer(ctx context.Context, ikto *ikto.Ikto, socket string) error {
 	s := &server{
 		ikto: ikto,
 	}
@@ -37,37 +41,337 @@ func StartAdminServer(ctx context.Context, ikto ikto.Ikto, socket string) error
 }
 
 type server struct {
-	ikto ikto.Ikto
+	ikto *ikto.Ikto
 }
 
 // NodeInfo implements proto.AdminServiceServer.
 func (s *server) NodeInfo(context.Context, *emptypb.Empty) (*proto.NodeInfoResponse, error) {
 	self := s.ikto.Self()
-	peers := s.ikto.Peers()
+	statuses := s.ikto.PeerStatuses()
+	relayed := s.ikto.RelayedPeers()
+	failovers := s.ikto.EndpointFailovers()
+	endpoints, err := s.ikto.PeerEndpoints()
+	if err != nil {
+		slog.Warn("failed to read peer endpoints", "error", err)
+	}
+
+	peersProto := make([]*proto.Peer, 0, len(statuses))
+
+	for _, status := range statuses {
+		peer := status.Peer
+
+		var presharedKeyNegotiated int64
+		if at, ok := s.ikto.PresharedKeyNegotiated(peer.PublicKey); ok {
+			presharedKeyNegotiated = at.Unix()
+		}
+
+		var relayedBy string
+		var relayedSince int64
+		if path, ok := relayed[peer.PublicKey]; ok {
+			relayedBy = path.Relay.String()
+			relayedSince = path.Since.Unix()
+		}
 
-	peersProto := make([]*proto.Peer, 0, len(peers))
+		endpointFailovers := make([]*proto.EndpointFailover, 0, len(failovers[peer.PublicKey]))
+		for _, failover := range failovers[peer.PublicKey] {
+			endpointFailovers = append(endpointFailovers, &proto.EndpointFailover{
+				From:   failover.From,
+				To:     failover.To,
+				Time:   failover.Time.Unix(),
+				Reason: failover.Reason,
+			})
+		}
 
-	for _, peer := range peers {
 		peersProto = append(peersProto, &proto.Peer{
 			Name:          peer.Name,
 			PublicKey:     peer.PublicKey.String(),
 			AdvertiseAddr: peer.AdvertiseAddress,
 			AllowedIp:     peer.AllowedIP,
+			SecondaryIp:   peer.SecondaryIP,
+			Routes:        peer.Routes,
+			ExitNode:      peer.ExitNode,
+			Labels:        peer.Labels,
+			Annotations:   peer.Annotations,
 			WgPort:        int32(peer.WGPort),
+			Relay:         peer.Relay,
+			Excluded:      !status.Selected,
+			Alive:         status.Alive,
+			LastSeen:      status.LastSeen.Unix(),
+
+			PresharedKeyNegotiated: presharedKeyNegotiated,
+			RelayedBy:              relayedBy,
+			RelayedSince:           relayedSince,
+			Endpoint:               endpoints[peer.PublicKey],
+			CandidateEndpoints:     peer.Candidates(),
+			EndpointFailovers:      endpointFailovers,
+			Tuning:                 tuningToProto(peer.Tuning),
 		})
 	}
 
 	return &proto.NodeInfoResponse{
 		Self: &proto.Peer{
-			Name:          self.Name,
-			PublicKey:     self.PublicKey.String(),
-			AdvertiseAddr: self.AdvertiseAddress,
-			AllowedIp:     self.AllowedIP,
-			WgPort:        int32(self.WGPort),
+			Name:               self.Name,
+			PublicKey:          self.PublicKey.String(),
+			AdvertiseAddr:      self.AdvertiseAddress,
+			AllowedIp:          self.AllowedIP,
+			SecondaryIp:        self.SecondaryIP,
+			Routes:             self.Routes,
+			ExitNode:           self.ExitNode,
+			Relay:              self.Relay,
+			Labels:             self.Labels,
+			CandidateEndpoints: self.Candidates(),
+			Annotations:        self.Annotations,
+			Tuning:             tuningToProto(self.Tuning),
+			WgPort:             int32(self.WGPort),
+			Alive:              true,
+			LastSeen:           time.Now().Unix(),
 		},
 		Peers: peersProto,
 	}, nil
 
 }
 
+// Leave implements proto.AdminServiceServer.
+func (s *server) Leave(ctx context.Context, req *proto.LeaveRequest) (*emptypb.Empty, error) {
+	err := s.ikto.Leave(ctx, req.RemoveDevice)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Status implements proto.AdminServiceServer.
+func (s *server) Status(context.Context, *emptypb.Empty) (*proto.StatusResponse, error) {
+	status := s.ikto.Status()
+	reconcile := s.ikto.ReconcileMetrics()
+
+	exitNode, exitNodeActive := s.ikto.ExitNode()
+
+	conflicts := []*proto.RouteConflict{}
+	for _, conflict := range s.ikto.RouteConflicts() {
+		conflicts = append(conflicts, &proto.RouteConflict{
+			Route: conflict.Route,
+			Peer:  conflict.Peer,
+			With:  conflict.With,
+		})
+	}
+
+	rejected := []*proto.RejectedPeer{}
+	for _, peer := range s.ikto.RejectedPeers() {
+		rejected = append(rejected, &proto.RejectedPeer{
+			Name:      peer.Peer.Name,
+			PublicKey: peer.Peer.PublicKey.String(),
+			AllowedIp: peer.Peer.AllowedIP,
+			Reason:    peer.Reason,
+			Since:     peer.Since.Unix(),
+		})
+	}
+
+	return &proto.StatusResponse{
+		NatsStatus:    status.NatsStatus,
+		NatsConnected: status.Connected,
+		Watching:      status.Sync.Watching,
+		LastSync:      status.Sync.LastSync.Unix(),
+		Resyncs:       status.Sync.Resyncs,
+		LastError:     status.Sync.LastError,
+		Reconcile: &proto.ReconcileMetrics{
+			Runs:            reconcile.Runs,
+			Failures:        reconcile.Failures,
+			DriftedRuns:     reconcile.DriftedRuns,
+			PrivateKeyFixes: reconcile.PrivateKeyFixes,
+			ListenPortFixes: reconcile.ListenPortFixes,
+			TuningFixes:     reconcile.TuningFixes,
+			AddedPeers:      reconcile.AddedPeers,
+			RemovedPeers:    reconcile.RemovedPeers,
+			UpdatedPeers:    reconcile.UpdatedPeers,
+			LastRun:         reconcile.LastRun.Unix(),
+			LastError:       reconcile.LastError,
+		},
+		RouteConflicts: conflicts,
+		ExitNode:       exitNode,
+		ExitNodeActive: exitNodeActive,
+		RejectedPeers:  rejected,
+	}, nil
+}
+
+// Reconcile implements proto.AdminServiceServer.
+func (s *server) Reconcile(ctx context.Context, req *proto.ReconcileRequest) (*proto.ReconcileResponse, error) {
+	drift, err := s.ikto.Reconcile(req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ReconcileResponse{
+		PrivateKey:    drift.PrivateKey,
+		ListenPort:    drift.ListenPort,
+		Tuning:        drift.Tuning,
+		MissingPeers:  keysToString(drift.MissingPeers),
+		UnknownPeers:  keysToString(drift.UnknownPeers),
+		ModifiedPeers: keysToString(drift.ModifiedPeers),
+	}, nil
+}
+
+func keysToString(keys []wgtypes.Key) []string {
+	s := make([]string, 0, len(keys))
+	for _, key := range keys {
+		s = append(s, key.String())
+	}
+	return s
+}
+
+// SetExitNode implements proto.AdminServiceServer.
+func (s *server) SetExitNode(ctx context.Context, req *proto.SetExitNodeRequest) (*emptypb.Empty, error) {
+	err := s.ikto.SetExitNode(req.Peer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// RotateKey implements proto.AdminServiceServer.
+func (s *server) RotateKey(ctx context.Context, _ *emptypb.Empty) (*proto.RotateKeyResponse, error) {
+	publicKey, err := s.ikto.RotateKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.RotateKeyResponse{
+		PublicKey: publicKey.String(),
+	}, nil
+}
+
+// ListPendingPeers implements proto.AdminServiceServer.
+func (s *server) ListPendingPeers(ctx context.Context, _ *emptypb.Empty) (*proto.ListPendingPeersResponse, error) {
+	pending, err := s.ikto.PendingPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]*proto.PendingPeer, 0, len(pending))
+	for _, request := range pending {
+		peers = append(peers, &proto.PendingPeer{
+			Peer:      recordToProto(request.Peer),
+			Requested: request.Requested.Unix(),
+			Rejection: request.Rejection,
+		})
+	}
+
+	return &proto.ListPendingPeersResponse{
+		Peers: peers,
+	}, nil
+}
+
+// ApprovePeer implements proto.AdminServiceServer.
+func (s *server) ApprovePeer(ctx context.Context, req *proto.ApprovePeerRequest) (*proto.ApprovePeerResponse, error) {
+	peer, err := s.ikto.ApprovePeer(ctx, req.Peer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ApprovePeerResponse{
+		Peer: recordToProto(peer),
+	}, nil
+}
+
+// SetLabels implements proto.AdminServiceServer.
+func (s *server) SetLabels(ctx context.Context, req *proto.SetMetadataRequest) (*proto.Peer, error) {
+	self, err := s.ikto.SetLabels(ctx, req.Set, req.Remove)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordToProto(self), nil
+}
+
+// SetAnnotations implements proto.AdminServiceServer.
+func (s *server) SetAnnotations(ctx context.Context, req *proto.SetMetadataRequest) (*proto.Peer, error) {
+	self, err := s.ikto.SetAnnotations(ctx, req.Set, req.Remove)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordToProto(self), nil
+}
+
+// ListPolicies implements proto.AdminServiceServer.
+func (s *server) ListPolicies(ctx context.Context, _ *emptypb.Empty) (*proto.ListPoliciesResponse, error) {
+	policies, err := s.ikto.Policies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policiesProto := make([]*proto.Policy, 0, len(policies))
+	for _, policy := range policies {
+		policiesProto = append(policiesProto, &proto.Policy{
+			Name:       policy.Name,
+			Nodes:      policy.Nodes,
+			Peers:      policy.Peers,
+			SameLabels: policy.SameLabels,
+			Signature:  policy.Signature,
+		})
+	}
+
+	return &proto.ListPoliciesResponse{
+		Policies: policiesProto,
+	}, nil
+}
+
+// PutPolicy implements proto.AdminServiceServer.
+func (s *server) PutPolicy(ctx context.Context, req *proto.Policy) (*emptypb.Empty, error) {
+	err := s.ikto.PutPolicy(ctx, types.Policy{
+		Name:       req.Name,
+		Nodes:      req.Nodes,
+		Peers:      req.Peers,
+		SameLabels: req.SameLabels,
+		Signature:  req.Signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeletePolicy implements proto.AdminServiceServer.
+func (s *server) DeletePolicy(ctx context.Context, req *proto.DeletePolicyRequest) (*emptypb.Empty, error) {
+	err := s.ikto.DeletePolicy(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func recordToProto(peer types.Peer) *proto.Peer {
+	return &proto.Peer{
+		Name:          peer.Name,
+		PublicKey:     peer.PublicKey.String(),
+		AdvertiseAddr: peer.AdvertiseAddress,
+		AllowedIp:     peer.AllowedIP,
+		SecondaryIp:   peer.SecondaryIP,
+		Routes:        peer.Routes,
+		ExitNode:      peer.ExitNode,
+		Relay:         peer.Relay,
+		Labels:        peer.Labels,
+		Annotations:   peer.Annotations,
+		WgPort:        int32(peer.WGPort),
+		Tuning:        tuningToProto(peer.Tuning),
+	}
+}
+
+func tuningToProto(tuning types.Tuning) *proto.Tuning {
+	if tuning == (types.Tuning{}) {
+		return nil
+	}
+
+	return &proto.Tuning{
+		PersistentKeepalive: int32(tuning.PersistentKeepalive),
+		Mtu:                 int32(tuning.MTU),
+		Fwmark:              uint32(tuning.FirewallMark),
+		Txqueuelen:          int32(tuning.TxQueueLen),
+	}
+}
+
 var _ proto.AdminServiceServer = (*server)(nil)
@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// Approval records the admission of PublicKey, as the key of the node Name,
+// by the node named Approver. A node approves its rotated key itself, with
+// the approval of its first key as Previous.
+type Approval struct {
+	PublicKey PublicKey `json:"public_key"`
+	Name      string    `json:"name"`
+	Approver  string    `json:"approver"`
+	Time      time.Time `json:"time"`
+	Previous  *Approval `json:"previous,omitempty"`
+
+	Signed      time.Time    `json:"signed,omitzero"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+	Signature   []byte       `json:"signature,omitempty"`
+}
@@ -0,0 +1,15 @@
+package types
+
+import "time"
+
+// Certificate is issued by the mesh authority to bind the name of a node to
+// the ed25519 key signing its peer records, its wireguard key and the
+// prefixes of its addresses.
+type Certificate struct {
+	Name      string    `json:"name"`
+	NodeKey   []byte    `json:"node_key"`
+	PublicKey PublicKey `json:"public_key"`
+	Prefixes  []string  `json:"prefixes"`
+	NotAfter  time.Time `json:"not_after"`
+	Signature []byte    `json:"signature"`
+}
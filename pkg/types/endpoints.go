@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// ObservedEndpoints are the endpoints of the peers seen by a node's device.
+type ObservedEndpoints struct {
+	// Name and PublicKey are those of the observing node.
+	Name      string            `json:"name"`
+	PublicKey PublicKey         `json:"public_key"`
+	Endpoints map[string]string `json:"endpoints"`
+	Time      time.Time         `json:"time"`
+
+	Signed      time.Time    `json:"signed,omitzero"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+	Signature   []byte       `json:"signature,omitempty"`
+}
@@ -0,0 +1,8 @@
+package types
+
+import "time"
+
+type Heartbeat struct {
+	PublicKey PublicKey `json:"public_key"`
+	Time      time.Time `json:"time"`
+}
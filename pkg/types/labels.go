@@ -0,0 +1,156 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+// ValidateLabelKey accepts up to 63 alphanumerics, '.', '_', '/' or '-',
+// starting and ending with an alphanumeric.
+func ValidateLabelKey(key string) error {
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+
+	return nil
+}
+
+// ValidateLabels accepts values following the rules of keys without '/'.
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if err := ValidateLabelKey(key); err != nil {
+			return err
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid value %q for label %q", value, key)
+		}
+	}
+
+	return nil
+}
+
+func ValidateAnnotations(annotations map[string]string) error {
+	for key := range annotations {
+		if err := ValidateLabelKey(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type selectorOperator int
+
+const (
+	selectorEquals selectorOperator = iota
+	selectorNotEquals
+	selectorExists
+	selectorNotExists
+)
+
+type requirement struct {
+	key      string
+	operator selectorOperator
+	value    string
+}
+
+func (r requirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+
+	switch r.operator {
+	case selectorEquals:
+		return ok && value == r.value
+	case selectorNotEquals:
+		return !ok || value != r.value
+	case selectorExists:
+		return ok
+	default:
+		return !ok
+	}
+}
+
+func (r requirement) String() string {
+	switch r.operator {
+	case selectorEquals:
+		return r.key + "=" + r.value
+	case selectorNotEquals:
+		return r.key + "!=" + r.value
+	case selectorExists:
+		return r.key
+	default:
+		return "!" + r.key
+	}
+}
+
+// Selector selects peers by their labels.
+type Selector struct {
+	requirements []requirement
+}
+
+// ParseSelector parses comma separated "key=value", "key!=value", "key" and
+// "!key" requirements.
+func ParseSelector(selector string) (Selector, error) {
+	var s Selector
+	if strings.TrimSpace(selector) == "" {
+		return s, nil
+	}
+
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+
+		var r requirement
+		switch {
+		case strings.Contains(term, "!="):
+			r.key, r.value, _ = strings.Cut(term, "!=")
+			r.operator = selectorNotEquals
+		case strings.Contains(term, "="):
+			r.key, r.value, _ = strings.Cut(term, "=")
+			r.operator = selectorEquals
+		case strings.HasPrefix(term, "!"):
+			r.key = strings.TrimPrefix(term, "!")
+			r.operator = selectorNotExists
+		default:
+			r.key = term
+			r.operator = selectorExists
+		}
+
+		r.key = strings.TrimSpace(r.key)
+		r.value = strings.TrimSpace(r.value)
+		if err := ValidateLabels(map[string]string{r.key: r.value}); err != nil {
+			return Selector{}, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+
+		s.requirements = append(s.requirements, r)
+	}
+
+	return s, nil
+}
+
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s.requirements {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s Selector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+func (s Selector) String() string {
+	terms := make([]string, 0, len(s.requirements))
+	for _, r := range s.requirements {
+		terms = append(terms, r.String())
+	}
+
+	return strings.Join(terms, ",")
+}
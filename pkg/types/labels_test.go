@@ -0,0 +1,40 @@
+package types
+
+import "testing"
+
+func TestSelector(t *testing.T) {
+	labels := map[string]string{"role": "hub", "region": "eu-west"}
+
+	tests := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"role=hub", true},
+		{"role=edge", false},
+		{"role=hub, region=eu-west", true},
+		{"role=hub,region=us-east", false},
+		{"role!=edge", true},
+		{"zone!=a", true},
+		{"region", true},
+		{"zone", false},
+		{"!zone", true},
+		{"!role", false},
+	}
+
+	for _, test := range tests {
+		selector, err := ParseSelector(test.selector)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", test.selector, err)
+		}
+		if got := selector.Matches(labels); got != test.matches {
+			t.Errorf("%q: expected %v, got %v", test.selector, test.matches, got)
+		}
+	}
+
+	for _, invalid := range []string{"=hub", "role=a b", "role,", "-role"} {
+		if _, err := ParseSelector(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
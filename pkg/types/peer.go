@@ -3,6 +3,9 @@ package types
 import (
 	"fmt"
 	"net"
+	"net/netip"
+	"strconv"
+	"time"
 
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
@@ -12,26 +15,130 @@ type Peer struct {
 	PublicKey        PublicKey `json:"public_key"`
 	AdvertiseAddress string    `json:"advertise_address"`
 	AllowedIP        string    `json:"allowed_ip"`
+	SecondaryIP      string    `json:"secondary_ip,omitempty"`
 	WGPort           int       `json:"wg_port"`
+	// Endpoints replace the advertise address when present.
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	Routes   []string `json:"routes,omitempty"`
+	ExitNode bool     `json:"exit_node,omitempty"`
+	Relay    bool     `json:"relay,omitempty"`
+	Tuning   Tuning   `json:"tuning,omitzero"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Signature covers the rest of the record when the mesh has an authority,
+	// and Signed orders the records of the node.
+	Signed      time.Time    `json:"signed,omitzero"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+	Signature   []byte       `json:"signature,omitempty"`
+
+	// RelayedBy, Endpoint and Keepalive are only set on the configuration
+	// applied to the device.
+	RelayedBy *PublicKey    `json:"-"`
+	Endpoint  string        `json:"-"`
+	Keepalive time.Duration `json:"-"`
 }
 
-func (p *Peer) WGPeerConfig() (wgtypes.PeerConfig, error) {
+const (
+	// RelayProbeInterval keeps WireGuard trying the direct path.
+	RelayProbeInterval   = 25 * time.Second
+	NATKeepaliveInterval = 25 * time.Second
+)
+
+// Candidates returns the candidate endpoints, or the advertise address.
+func (p *Peer) Candidates() []string {
+	if len(p.Endpoints) > 0 {
+		return p.Endpoints
+	}
+
+	if p.AdvertiseAddress == "" {
+		return nil
+	}
+
+	return []string{net.JoinHostPort(p.AdvertiseAddress, strconv.Itoa(p.WGPort))}
+}
+
+// endpoint is nil when WireGuard must learn it from the handshakes.
+func (p *Peer) endpoint() (*net.UDPAddr, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		candidates := p.Candidates()
+		if len(candidates) == 0 {
+			return nil, nil
+		}
+		endpoint = candidates[0]
+	}
+
+	addrPort, err := netip.ParseAddrPort(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+
+	return net.UDPAddrFromAddrPort(addrPort), nil
+}
+
+func (p *Peer) Subnets() ([]net.IPNet, error) {
 	_, ipnet, err := net.ParseCIDR(p.AllowedIP)
+	if err != nil {
+		return nil, err
+	}
+
+	subnets := []net.IPNet{*ipnet}
+	if p.SecondaryIP != "" {
+		_, secondary, err := net.ParseCIDR(p.SecondaryIP)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, *secondary)
+	}
+
+	return subnets, nil
+}
+
+func (p *Peer) AdvertisedRoutes() ([]net.IPNet, error) {
+	routes := make([]net.IPNet, 0, len(p.Routes))
+	for _, route := range p.Routes {
+		_, ipnet, err := net.ParseCIDR(route)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, *ipnet)
+	}
+
+	return routes, nil
+}
+
+func (p *Peer) WGPeerConfig() (wgtypes.PeerConfig, error) {
+	subnets, err := p.Subnets()
+	if err != nil {
+		return wgtypes.PeerConfig{}, err
+	}
+
+	routes, err := p.AdvertisedRoutes()
+	if err != nil {
+		return wgtypes.PeerConfig{}, err
+	}
+
+	endpoint, err := p.endpoint()
 	if err != nil {
 		return wgtypes.PeerConfig{}, err
 	}
 
-	advertiseAddress := net.ParseIP(p.AdvertiseAddress)
-	if advertiseAddress == nil {
-		return wgtypes.PeerConfig{}, fmt.Errorf("invalid public address: %s", p.AdvertiseAddress)
+	allowedIPs := append(subnets, routes...)
+	keepalive := p.Keepalive
+	if p.RelayedBy != nil {
+		allowedIPs = []net.IPNet{}
+		if keepalive == 0 {
+			keepalive = RelayProbeInterval
+		}
 	}
 
 	return wgtypes.PeerConfig{
-		PublicKey: p.PublicKey.WG(),
-		Endpoint: &net.UDPAddr{
-			IP:   advertiseAddress,
-			Port: p.WGPort,
-		},
-		AllowedIPs: []net.IPNet{*ipnet},
+		PublicKey:                   p.PublicKey.WG(),
+		Endpoint:                    endpoint,
+		AllowedIPs:                  allowedIPs,
+		PersistentKeepaliveInterval: &keepalive,
 	}, nil
 }
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var policyNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_-]{0,61}[A-Za-z0-9])?$`)
+
+// Policy restricts the peers of the nodes it applies to. Two nodes only peer
+// if both allow it.
+type Policy struct {
+	Name  string `json:"name"`
+	Nodes string `json:"nodes"`
+	Peers string `json:"peers"`
+	// SameLabels must have the same value on both nodes.
+	SameLabels []string `json:"same_labels,omitempty"`
+	Signature  []byte   `json:"signature,omitempty"`
+}
+
+func (p Policy) Validate() error {
+	_, err := compilePolicy(p)
+	return err
+}
+
+type compiledPolicy struct {
+	nodes      Selector
+	peers      Selector
+	sameLabels []string
+}
+
+func compilePolicy(p Policy) (compiledPolicy, error) {
+	if !policyNamePattern.MatchString(p.Name) {
+		return compiledPolicy{}, fmt.Errorf("invalid policy name %q", p.Name)
+	}
+
+	nodes, err := ParseSelector(p.Nodes)
+	if err != nil {
+		return compiledPolicy{}, fmt.Errorf("policy %s: nodes: %w", p.Name, err)
+	}
+
+	peers, err := ParseSelector(p.Peers)
+	if err != nil {
+		return compiledPolicy{}, fmt.Errorf("policy %s: peers: %w", p.Name, err)
+	}
+
+	for _, key := range p.SameLabels {
+		if err := ValidateLabelKey(key); err != nil {
+			return compiledPolicy{}, fmt.Errorf("policy %s: same labels: %w", p.Name, err)
+		}
+	}
+
+	return compiledPolicy{
+		nodes:      nodes,
+		peers:      peers,
+		sameLabels: p.SameLabels,
+	}, nil
+}
+
+func (p compiledPolicy) allows(local map[string]string, remote map[string]string) bool {
+	if !p.peers.Matches(remote) {
+		return false
+	}
+
+	for _, key := range p.sameLabels {
+		value, ok := local[key]
+		if !ok || remote[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Topology decides which nodes peer with each other. The zero Topology lets
+// every node peer with every other node.
+type Topology struct {
+	policies []compiledPolicy
+}
+
+func NewTopology(policies []Policy) (Topology, error) {
+	var t Topology
+	for _, policy := range policies {
+		compiled, err := compilePolicy(policy)
+		if err != nil {
+			return Topology{}, err
+		}
+		t.policies = append(t.policies, compiled)
+	}
+
+	return t, nil
+}
+
+// Allows reports whether the nodes labelled a and b peer with each other.
+func (t Topology) Allows(a map[string]string, b map[string]string) bool {
+	return t.allows(a, b) && t.allows(b, a)
+}
+
+func (t Topology) allows(local map[string]string, remote map[string]string) bool {
+	applied := false
+	for _, policy := range t.policies {
+		if !policy.nodes.Matches(local) {
+			continue
+		}
+		if policy.allows(local, remote) {
+			return true
+		}
+		applied = true
+	}
+
+	return !applied
+}
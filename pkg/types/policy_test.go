@@ -0,0 +1,52 @@
+package types
+
+import "testing"
+
+func TestTopology(t *testing.T) {
+	topology, err := NewTopology([]Policy{{
+		Name:       "edges-to-hubs",
+		Nodes:      "role=edge",
+		Peers:      "role=hub",
+		SameLabels: []string{"region"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create topology: %v", err)
+	}
+
+	hubEU := map[string]string{"role": "hub", "region": "eu"}
+	hubUS := map[string]string{"role": "hub", "region": "us"}
+	edgeEU := map[string]string{"role": "edge", "region": "eu"}
+	otherEdgeEU := map[string]string{"role": "edge", "region": "eu", "rack": "b"}
+	unlabelled := map[string]string{}
+
+	tests := []struct {
+		name   string
+		a, b   map[string]string
+		allows bool
+	}{
+		{"hubs", hubEU, hubUS, true},
+		{"edge and hub of its region", edgeEU, hubEU, true},
+		{"hub and edge of its region", hubEU, edgeEU, true},
+		{"edge and hub of another region", edgeEU, hubUS, false},
+		{"edges", edgeEU, otherEdgeEU, false},
+		{"edge and unlabelled node", edgeEU, unlabelled, false},
+		{"unlabelled nodes", unlabelled, unlabelled, true},
+	}
+
+	for _, test := range tests {
+		if got := topology.Allows(test.a, test.b); got != test.allows {
+			t.Errorf("%s: expected %v, got %v", test.name, test.allows, got)
+		}
+	}
+
+	if !(Topology{}).Allows(edgeEU, otherEdgeEU) {
+		t.Error("expected the empty topology to allow every pair")
+	}
+
+	if err := (Policy{Name: "bad name", Nodes: "role=edge"}).Validate(); err == nil {
+		t.Error("expected an invalid name to be refused")
+	}
+	if err := (Policy{Name: "bad-selector", Nodes: "role=a b"}).Validate(); err == nil {
+		t.Error("expected an invalid selector to be refused")
+	}
+}
@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// RelayPaths are the peers a node reaches through a relay, so that the peers
+// reach it through the same relay.
+type RelayPaths struct {
+	Name      string    `json:"name"`
+	PublicKey PublicKey `json:"public_key"`
+	// Relays maps the public keys of the peers to the public key of their
+	// relay.
+	Relays map[string]string `json:"relays"`
+	Time   time.Time         `json:"time"`
+
+	Signed      time.Time    `json:"signed,omitzero"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+	Signature   []byte       `json:"signature,omitempty"`
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Tuning holds the tunables of the WireGuard interfaces. Zero is unset.
+type Tuning struct {
+	// PersistentKeepalive is in seconds.
+	PersistentKeepalive int `json:"persistent_keepalive,omitempty"`
+	MTU                 int `json:"mtu,omitempty"`
+	FirewallMark        int `json:"fwmark,omitempty"`
+	TxQueueLen          int `json:"txqueuelen,omitempty"`
+}
+
+func (t Tuning) Override(overrides Tuning) Tuning {
+	if overrides.PersistentKeepalive != 0 {
+		t.PersistentKeepalive = overrides.PersistentKeepalive
+	}
+	if overrides.MTU != 0 {
+		t.MTU = overrides.MTU
+	}
+	if overrides.FirewallMark != 0 {
+		t.FirewallMark = overrides.FirewallMark
+	}
+	if overrides.TxQueueLen != 0 {
+		t.TxQueueLen = overrides.TxQueueLen
+	}
+
+	return t
+}
+
+func (t Tuning) Validate() error {
+	if t.PersistentKeepalive < 0 || t.PersistentKeepalive > math.MaxUint16 {
+		return fmt.Errorf("persistent keepalive must be between 0 and %d seconds", math.MaxUint16)
+	}
+	if t.MTU != 0 && (t.MTU < 576 || t.MTU > math.MaxUint16) {
+		return fmt.Errorf("mtu must be between 576 and %d", math.MaxUint16)
+	}
+	if t.FirewallMark < 0 || int64(t.FirewallMark) > math.MaxUint32 {
+		return fmt.Errorf("firewall mark must be between 0 and %d", uint32(math.MaxUint32))
+	}
+	if t.TxQueueLen < 0 {
+		return fmt.Errorf("txqueuelen must not be negative")
+	}
+
+	return nil
+}
+
+func (t Tuning) Keepalive() time.Duration {
+	return time.Duration(t.PersistentKeepalive) * time.Second
+}
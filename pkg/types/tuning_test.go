@@ -0,0 +1,31 @@
+package types
+
+import "testing"
+
+func TestTuning(t *testing.T) {
+	defaults := Tuning{PersistentKeepalive: 25, MTU: 1420}
+	tuning := defaults.Override(Tuning{MTU: 1380, FirewallMark: 0x51820})
+
+	expected := Tuning{PersistentKeepalive: 25, MTU: 1380, FirewallMark: 0x51820}
+	if tuning != expected {
+		t.Fatalf("expected %+v, got %+v", expected, tuning)
+	}
+	if got := tuning.Keepalive().String(); got != "25s" {
+		t.Fatalf("unexpected keepalive %s", got)
+	}
+
+	for _, invalid := range []Tuning{
+		{PersistentKeepalive: -1},
+		{PersistentKeepalive: 65536},
+		{MTU: 100},
+		{FirewallMark: -1},
+		{TxQueueLen: -1},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", invalid)
+		}
+	}
+	if err := tuning.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}